@@ -0,0 +1,7 @@
+-- name: PurgeDeletedBots :many
+WITH purged AS (
+    DELETE FROM bot WHERE deleted < @before RETURNING id
+), avatars AS (
+    DELETE FROM avatar WHERE bot_id IN (SELECT id FROM purged)
+)
+SELECT id FROM purged;
@@ -6,6 +6,7 @@ package db
 
 import (
 	"context"
+	"database/sql"
 
 	"encore.dev/types/uuid"
 )
@@ -19,6 +20,7 @@ type Querier interface {
 	InsertAvatar(ctx context.Context, db DBTX, arg InsertAvatarParams) error
 	InsertBot(ctx context.Context, db DBTX, arg InsertBotParams) (*Bot, error)
 	ListBot(ctx context.Context, db DBTX) ([]*Bot, error)
+	PurgeDeletedBots(ctx context.Context, db DBTX, before sql.NullTime) ([]uuid.UUID, error)
 }
 
 var _ Querier = (*Queries)(nil)
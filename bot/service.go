@@ -5,6 +5,7 @@ package bot
 import (
 	"bytes"
 	"context"
+	"database/sql"
 	"net/http"
 	"strings"
 	"time"
@@ -130,6 +131,26 @@ func (svc *Service) Delete(ctx context.Context, id uuid.UUID) (*db.Bot, error) {
 	return db.New().DeleteBot(ctx, botdb.Stdlib(), id)
 }
 
+type PurgeDeletedRequest struct {
+	// Before is the time before which bots must have been deleted to be purged
+	Before time.Time
+}
+
+type PurgeDeletedResponse struct {
+	Bots []uuid.UUID
+}
+
+// PurgeDeleted permanently removes bots, including their avatars, which were deleted before a given time.
+//
+//encore:api private method=POST path=/bots/purge
+func (svc *Service) PurgeDeleted(ctx context.Context, req *PurgeDeletedRequest) (*PurgeDeletedResponse, error) {
+	ids, err := db.New().PurgeDeletedBots(ctx, botdb.Stdlib(), sql.NullTime{Time: req.Before, Valid: true})
+	if err != nil {
+		return nil, errors.Wrap(err, "purge deleted bots")
+	}
+	return &PurgeDeletedResponse{Bots: ids}, nil
+}
+
 // Avatar returns the avatar image of a bot by ID.
 //
 //encore:api public raw path=/bots/:id/avatar
@@ -0,0 +1,163 @@
+package provider
+
+import (
+	"context"
+	"io"
+	"mime"
+	"net/http"
+	"path"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/cockroachdb/errors"
+)
+
+// Attachment types
+const (
+	AttachmentTypeFile  = "file"
+	AttachmentTypeImage = "image"
+	AttachmentTypeLink  = "link"
+	AttachmentTypeCode  = "code"
+)
+
+// MaxAttachmentText is the maximum number of bytes of text extracted from an attachment
+const MaxAttachmentText = 8 << 10
+
+// Attachment is a file, link or code snippet attached to a message
+type Attachment struct {
+	Type string
+	// Name is the file name of files, images and code snippets and the title of links
+	Name string
+	URL  string
+	// MimeType is the media type of files, e.g. application/pdf
+	MimeType string
+	// Size is the size of files in bytes
+	Size int64
+	// Description is the unfurled description of links
+	Description string
+	// Text is the extracted text of text files and the content of code snippets, at most MaxAttachmentText bytes
+	Text string
+}
+
+// codeLanguages maps file extensions of code snippets to their language
+var codeLanguages = map[string]string{
+	".c":     "c",
+	".cpp":   "cpp",
+	".cs":    "csharp",
+	".css":   "css",
+	".go":    "go",
+	".html":  "html",
+	".java":  "java",
+	".js":    "javascript",
+	".json":  "json",
+	".kt":    "kotlin",
+	".py":    "python",
+	".rb":    "ruby",
+	".rs":    "rust",
+	".sh":    "shell",
+	".sql":   "sql",
+	".swift": "swift",
+	".ts":    "typescript",
+	".yaml":  "yaml",
+	".yml":   "yaml",
+}
+
+// NewFileAttachment creates an attachment for a file. Its type is derived from the media type and file name.
+func NewFileAttachment(name, url, mimeType string, size int64) Attachment {
+	if mimeType == "" {
+		mimeType = mime.TypeByExtension(path.Ext(name))
+	}
+	// Drop parameters like the charset
+	mimeType, _, _ = strings.Cut(mimeType, ";")
+	att := Attachment{
+		Type:     AttachmentTypeFile,
+		Name:     name,
+		URL:      url,
+		MimeType: strings.TrimSpace(mimeType),
+		Size:     size,
+	}
+	if CodeLanguage(name) != "" {
+		att.Type = AttachmentTypeCode
+	} else if strings.HasPrefix(att.MimeType, "image/") {
+		att.Type = AttachmentTypeImage
+	}
+	return att
+}
+
+// CodeLanguage returns the programming language of a code file by its name, or an empty string if it isn't code.
+func CodeLanguage(name string) string {
+	return codeLanguages[strings.ToLower(path.Ext(name))]
+}
+
+// HasText returns true if text can be extracted from the attachment, i.e. it's a plain text or markdown file or a
+// code snippet.
+func (a *Attachment) HasText() bool {
+	switch {
+	case a.Type == AttachmentTypeCode:
+		return true
+	case a.Type != AttachmentTypeFile:
+		return false
+	case a.MimeType == "text/plain", a.MimeType == "text/markdown", a.MimeType == "text/x-markdown":
+		return true
+	}
+	ext := strings.ToLower(path.Ext(a.Name))
+	return ext == ".txt" || ext == ".md" || ext == ".markdown"
+}
+
+// ReadText sets the text of the attachment from r. The text is truncated to MaxAttachmentText bytes. Files which
+// aren't valid UTF-8 are ignored.
+func (a *Attachment) ReadText(r io.Reader) error {
+	data, err := io.ReadAll(io.LimitReader(r, MaxAttachmentText))
+	if err != nil {
+		return errors.Wrap(err, "read text")
+	}
+	// The limit might have cut a multibyte character in half
+	for i := 0; i < utf8.UTFMax-1 && len(data) > 0 && !utf8.Valid(data); i++ {
+		data = data[:len(data)-1]
+	}
+	if !utf8.Valid(data) {
+		return nil
+	}
+	a.Text = string(data)
+	return nil
+}
+
+// FetchText downloads the attachment from its URL and sets its text. The header is added to the request, e.g. to
+// authenticate to the provider. Attachments without text are left as is.
+func (a *Attachment) FetchText(ctx context.Context, header http.Header) error {
+	if !a.HasText() || a.URL == "" || a.Text != "" {
+		return nil
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, a.URL, nil)
+	if err != nil {
+		return errors.Wrap(err, "create request")
+	}
+	for k, v := range header {
+		req.Header[k] = v
+	}
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return errors.Wrap(err, "download attachment")
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return errors.Newf("download attachment: %s", resp.Status)
+	}
+	return a.ReadText(resp.Body)
+}
+
+// RenderAttachments appends the attachments to the content of a message as text, for providers which can't upload
+// the attachments themselves. Links are appended as URLs and code snippets as code blocks.
+func RenderAttachments(content string, attachments []Attachment) string {
+	var b strings.Builder
+	b.WriteString(content)
+	for _, a := range attachments {
+		switch {
+		case a.Type == AttachmentTypeCode && a.Text != "":
+			b.WriteString("\n```" + CodeLanguage(a.Name) + "\n" + strings.TrimSuffix(a.Text, "\n") + "\n```")
+		case a.URL != "":
+			b.WriteString("\n" + a.URL)
+		}
+	}
+	return strings.TrimSpace(b.String())
+}
@@ -0,0 +1,49 @@
+package discord
+
+import (
+	"context"
+
+	discord "github.com/bwmarrin/discordgo"
+
+	"encore.app/chat/provider"
+	"encore.dev/rlog"
+)
+
+// toAttachments converts the files and embedded links of a message to provider attachments. The text of text files
+// is downloaded if ExtractAttachmentText is enabled.
+func toAttachments(ctx context.Context, msg *discord.Message) []provider.Attachment {
+	var rtn []provider.Attachment
+	for _, a := range msg.Attachments {
+		att := provider.NewFileAttachment(a.Filename, a.URL, a.ContentType, int64(a.Size))
+		if cfg.ExtractAttachmentText() {
+			// Attachment URLs are signed, so they can be downloaded without authentication
+			if err := att.FetchText(ctx, nil); err != nil {
+				rlog.Warn("error fetching attachment text", "attachment", a.ID, "error", err)
+			}
+		}
+		rtn = append(rtn, att)
+	}
+	return append(rtn, toLinks(msg.Embeds)...)
+}
+
+// toLinks converts the embeds Discord generates for links in a message to link attachments. Rich embeds are
+// created by bots and webhooks and don't represent a link, so they are skipped.
+func toLinks(embeds []*discord.MessageEmbed) []provider.Attachment {
+	var rtn []provider.Attachment
+	for _, e := range embeds {
+		if e.URL == "" || e.Type == discord.EmbedTypeRich {
+			continue
+		}
+		name := e.Title
+		if name == "" && e.Provider != nil {
+			name = e.Provider.Name
+		}
+		rtn = append(rtn, provider.Attachment{
+			Type:        provider.AttachmentTypeLink,
+			Name:        name,
+			URL:         e.URL,
+			Description: e.Description,
+		})
+	}
+	return rtn
+}
@@ -0,0 +1,206 @@
+package discord
+
+import (
+	"context"
+	"slices"
+
+	discord "github.com/bwmarrin/discordgo"
+	"github.com/cockroachdb/errors"
+
+	"encore.app/chat/provider"
+	chatdb "encore.app/chat/service/db"
+	"encore.dev/rlog"
+)
+
+// maxMessageLength is the maximum length of a Discord message
+const maxMessageLength = 2000
+
+// botCommand is the /bot application command used to manage bots in a channel
+var botCommand = &discord.ApplicationCommand{
+	Name:         "bot",
+	Description:  "Manage the bots in this channel",
+	DMPermission: new(bool),
+	Options: []*discord.ApplicationCommandOption{
+		{
+			Type:        discord.ApplicationCommandOptionSubCommand,
+			Name:        provider.CommandAddBot,
+			Description: "Add a bot to this channel",
+			Options:     []*discord.ApplicationCommandOption{botNameOption},
+		},
+		{
+			Type:        discord.ApplicationCommandOptionSubCommand,
+			Name:        provider.CommandRemoveBot,
+			Description: "Remove a bot from this channel",
+			Options:     []*discord.ApplicationCommandOption{botNameOption},
+		},
+		{
+			Type:        discord.ApplicationCommandOptionSubCommand,
+			Name:        provider.CommandListBots,
+			Description: "List the bots in this channel",
+		},
+		{
+			Type:        discord.ApplicationCommandOptionSubCommand,
+			Name:        provider.CommandInstruct,
+			Description: "Instruct a bot in this channel, e.g. to start a new topic",
+			Options: []*discord.ApplicationCommandOption{
+				botNameOption,
+				{
+					Type:        discord.ApplicationCommandOptionString,
+					Name:        "instruction",
+					Description: "What the bot should do",
+					Required:    true,
+				},
+			},
+		},
+		{
+			Type:        discord.ApplicationCommandOptionSubCommand,
+			Name:        provider.CommandCreateBot,
+			Description: "Create a new bot",
+			Options: []*discord.ApplicationCommandOption{
+				botNameOption,
+				{
+					Type:        discord.ApplicationCommandOptionString,
+					Name:        "prompt",
+					Description: "A description of the bot's personality",
+					Required:    true,
+				},
+				{
+					Type:        discord.ApplicationCommandOptionString,
+					Name:        "llm",
+					Description: "The LLM provider of the bot",
+					Choices: []*discord.ApplicationCommandOptionChoice{
+						{Name: "OpenAI", Value: "openai"},
+						{Name: "Gemini", Value: "gemini"},
+					},
+				},
+			},
+		},
+	},
+}
+
+var botNameOption = &discord.ApplicationCommandOption{
+	Type:        discord.ApplicationCommandOptionString,
+	Name:        "name",
+	Description: "The name of the bot",
+	Required:    true,
+}
+
+// registerCommands registers the application commands when the session is ready.
+func (c *Service) registerCommands(sess *discord.Session, r *discord.Ready) {
+	_, err := sess.ApplicationCommandBulkOverwrite(r.Application.ID, "", []*discord.ApplicationCommand{botCommand})
+	if err != nil {
+		rlog.Error("error registering commands", "error", err)
+	}
+}
+
+// handleCommand handles /bot commands. The command is acknowledged right away and published to the command topic,
+// the chat service responds to it when it's done.
+func (c *Service) handleCommand(sess *discord.Session, i *discord.InteractionCreate) {
+	if i.Type != discord.InteractionApplicationCommand {
+		return
+	}
+	data := i.ApplicationCommandData()
+	if data.Name != botCommand.Name || len(data.Options) == 0 {
+		return
+	}
+	sub := data.Options[0]
+	cmd := &provider.Command{
+		Provider:  chatdb.ProviderDiscord,
+		ChannelID: i.ChannelID,
+		Name:      sub.Name,
+		Token:     i.Token,
+	}
+	for _, opt := range sub.Options {
+		switch opt.Name {
+		case "name":
+			cmd.Bot = opt.StringValue()
+		case "instruction", "prompt":
+			cmd.Text = opt.StringValue()
+		case "llm":
+			cmd.LLM = opt.StringValue()
+		}
+	}
+	// Bots are managed in the parent channel of threads
+	if thread := c.thread(i.ChannelID); thread != nil {
+		cmd.ChannelID = thread.ParentID
+	}
+	if i.Member != nil && i.Member.User != nil {
+		cmd.User = provider.User{
+			ID:   i.Member.User.ID,
+			Name: i.Member.User.Username,
+		}
+	}
+	if cmd.Name != provider.CommandListBots && !c.canManageBots(i.GuildID, i.Member) {
+		c.respond(i.Interaction, "You don't have permission to manage bots in this server.")
+		return
+	}
+	err := sess.InteractionRespond(i.Interaction, &discord.InteractionResponse{
+		Type: discord.InteractionResponseDeferredChannelMessageWithSource,
+		Data: &discord.InteractionResponseData{Flags: discord.MessageFlagsEphemeral},
+	})
+	if err != nil {
+		rlog.Error("error acknowledging command", "error", err)
+		return
+	}
+	_, err = provider.CommandTopic.Publish(context.Background(), cmd)
+	if err != nil {
+		rlog.Error("error publishing command", "error", err)
+		c.respond(i.Interaction, "Something went wrong, please try again.")
+	}
+}
+
+// canManageBots returns true if the member is allowed to add, remove, instruct and create bots. Members need the
+// Manage Channels permission or one of the configured manager roles.
+func (c *Service) canManageBots(guildID string, member *discord.Member) bool {
+	if member == nil {
+		return false
+	}
+	if member.Permissions&(discord.PermissionAdministrator|discord.PermissionManageChannels) != 0 {
+		return true
+	}
+	managerRoles := cfg.ManagerRoles()
+	for _, roleID := range member.Roles {
+		role, err := c.client.State.Role(guildID, roleID)
+		if err != nil {
+			continue
+		}
+		if slices.Contains(managerRoles, role.Name) {
+			return true
+		}
+	}
+	return false
+}
+
+// respond responds to an interaction with an ephemeral message. If the interaction was already acknowledged,
+// the response is updated instead.
+func (c *Service) respond(i *discord.Interaction, content string) {
+	err := c.client.InteractionRespond(i, &discord.InteractionResponse{
+		Type: discord.InteractionResponseChannelMessageWithSource,
+		Data: &discord.InteractionResponseData{
+			Content: content,
+			Flags:   discord.MessageFlagsEphemeral,
+		},
+	})
+	if err == nil {
+		return
+	}
+	_, err = c.client.InteractionResponseEdit(i, &discord.WebhookEdit{Content: &content})
+	if err != nil {
+		rlog.Error("error responding to command", "error", err)
+	}
+}
+
+// RespondToCommand responds to a /bot command. The response is only visible to the user who issued the command.
+//
+//encore:api private method=POST path=/discord/commands/respond
+func (c *Service) RespondToCommand(ctx context.Context, req *provider.CommandResponse) error {
+	content := req.Content
+	if runes := []rune(content); len(runes) > maxMessageLength {
+		content = string(runes[:maxMessageLength-3]) + "..."
+	}
+	_, err := c.client.InteractionResponseEdit(&discord.Interaction{
+		AppID: c.client.State.Application.ID,
+		Token: req.Token,
+	}, &discord.WebhookEdit{Content: &content})
+	return errors.Wrap(err, "error responding to command")
+}
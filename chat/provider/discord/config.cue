@@ -0,0 +1,13 @@
+// ManagerRoles are the names of the server roles which are allowed to manage bots with the /bot command.
+// Members with the Manage Channels permission can always manage bots.
+ManagerRoles: [...string] | *[]
+
+// Guilds are the IDs of the servers whose channels are used. All servers the bot is a member of are used
+// if it's empty.
+Guilds: [...string] | *[]
+
+// ChannelTypes are the types of channels which are used: "text", "announcement", "voice" and "stage".
+ChannelTypes: [...("text" | "announcement" | "voice" | "stage")] | *["text", "announcement"]
+
+// ExtractAttachmentText downloads plain text and markdown files posted in Discord so the bots can read them.
+ExtractAttachmentText: bool | *true
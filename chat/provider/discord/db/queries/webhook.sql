@@ -9,4 +9,7 @@ SELECT * FROM webhook WHERE channel = $1 AND bot_id = $2 and deleted IS NULL;
 SELECT * FROM webhook WHERE provider_id=$1 and deleted IS NULL;
 
 -- name: DeleteWebhook :one
-UPDATE webhook SET deleted = CURRENT_TIMESTAMP WHERE id = $1 RETURNING *;
\ No newline at end of file
+UPDATE webhook SET deleted = CURRENT_TIMESTAMP WHERE id = $1 RETURNING *;
+
+-- name: ListWebhooks :many
+SELECT * FROM webhook WHERE deleted IS NULL;
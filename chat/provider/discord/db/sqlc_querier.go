@@ -15,6 +15,7 @@ type Querier interface {
 	GetWebhookByID(ctx context.Context, db DBTX, providerID string) (*Webhook, error)
 	GetWebhookForBot(ctx context.Context, db DBTX, arg GetWebhookForBotParams) (*Webhook, error)
 	InsertWebhook(ctx context.Context, db DBTX, arg InsertWebhookParams) (*Webhook, error)
+	ListWebhooks(ctx context.Context, db DBTX) ([]*Webhook, error)
 }
 
 var _ Querier = (*Queries)(nil)
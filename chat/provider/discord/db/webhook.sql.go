@@ -106,3 +106,38 @@ func (q *Queries) InsertWebhook(ctx context.Context, db DBTX, arg InsertWebhookP
 	)
 	return &i, err
 }
+
+const listWebhooks = `-- name: ListWebhooks :many
+SELECT id, provider_id, bot_id, channel, name, token, deleted FROM webhook WHERE deleted IS NULL
+`
+
+func (q *Queries) ListWebhooks(ctx context.Context, db DBTX) ([]*Webhook, error) {
+	rows, err := db.QueryContext(ctx, listWebhooks)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := []*Webhook{}
+	for rows.Next() {
+		var i Webhook
+		if err := rows.Scan(
+			&i.ID,
+			&i.ProviderID,
+			&i.BotID,
+			&i.Channel,
+			&i.Name,
+			&i.Token,
+			&i.Deleted,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, &i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
@@ -0,0 +1,144 @@
+package discord
+
+import (
+	"context"
+	"slices"
+
+	discord "github.com/bwmarrin/discordgo"
+	"github.com/cockroachdb/errors"
+
+	"encore.app/chat/provider"
+	chatdb "encore.app/chat/service/db"
+	"encore.dev/rlog"
+)
+
+// maxGuildPageSize is the maximum number of guilds Discord returns per request
+const maxGuildPageSize = 200
+
+// channelTypes maps the channel type names used in the ChannelTypes config to Discord channel types. Forum and
+// category channels can't be posted in, so they aren't supported.
+var channelTypes = map[string]discord.ChannelType{
+	"text":         discord.ChannelTypeGuildText,
+	"announcement": discord.ChannelTypeGuildNews,
+	"voice":        discord.ChannelTypeGuildVoice,
+	"stage":        discord.ChannelTypeGuildStageVoice,
+}
+
+// listGuilds returns all guilds the bot is a part of, regardless of the Guilds config.
+func (c *Service) listGuilds(ctx context.Context) ([]*discord.UserGuild, error) {
+	var rtn []*discord.UserGuild
+	after := ""
+	for {
+		guilds, err := c.client.UserGuilds(maxGuildPageSize, "", after, false, discord.WithContext(ctx))
+		if err != nil {
+			return nil, errors.Wrap(err, "error getting guilds")
+		}
+		rtn = append(rtn, guilds...)
+		if len(guilds) < maxGuildPageSize {
+			return rtn, nil
+		}
+		after = guilds[len(guilds)-1].ID
+	}
+}
+
+// guildEnabled returns true if the channels of the guild are used
+func guildEnabled(guildID string) bool {
+	guilds := cfg.Guilds()
+	return len(guilds) == 0 || slices.Contains(guilds, guildID)
+}
+
+// channelEnabled returns true if channels of the given type are used
+func channelEnabled(typ discord.ChannelType) bool {
+	for _, name := range cfg.ChannelTypes() {
+		if t, ok := channelTypes[name]; ok && t == typ {
+			return true
+		}
+	}
+	return false
+}
+
+// toChannelInfos converts the channels of the enabled types to channel infos
+func toChannelInfos(channels []*discord.Channel) []provider.ChannelInfo {
+	var rtn []provider.ChannelInfo
+	for _, channel := range channels {
+		if channelEnabled(channel.Type) {
+			rtn = append(rtn, toChannelInfo(channel))
+		}
+	}
+	return rtn
+}
+
+func toChannelInfo(channel *discord.Channel) provider.ChannelInfo {
+	return provider.ChannelInfo{
+		Provider: chatdb.ProviderDiscord,
+		ID:       channel.ID,
+		Name:     channel.Name,
+		GuildID:  channel.GuildID,
+	}
+}
+
+// handleGuildCreate publishes the channels of a guild when the bot joins it. It's also called for every guild the
+// bot is a part of when it connects. Deleted channels aren't part of the guild, so the listed channels are restored
+// if the bot was removed from the guild before.
+func (c *Service) handleGuildCreate(s *discord.Session, g *discord.GuildCreate) {
+	if !guildEnabled(g.ID) {
+		return
+	}
+	channels := toChannelInfos(g.Channels)
+	if len(channels) == 0 {
+		return
+	}
+	_, err := provider.ChannelTopic.Publish(context.Background(), &provider.ChannelEvent{
+		Provider: chatdb.ProviderDiscord,
+		Type:     provider.ChannelEventRestored,
+		Channels: channels,
+	})
+	if err != nil {
+		rlog.Error("error publishing guild channels", "guild", g.ID, "error", err)
+	}
+}
+
+// handleGuildDelete removes the channels of a guild when the bot is removed from it. Guilds which are only
+// unavailable due to an outage are kept.
+func (c *Service) handleGuildDelete(s *discord.Session, g *discord.GuildDelete) {
+	if g.Unavailable || !guildEnabled(g.ID) {
+		return
+	}
+	_, err := provider.ChannelTopic.Publish(context.Background(), &provider.ChannelEvent{
+		Provider: chatdb.ProviderDiscord,
+		Type:     provider.ChannelEventGuildRemoved,
+		GuildID:  g.ID,
+	})
+	if err != nil {
+		rlog.Error("error publishing removed guild", "guild", g.ID, "error", err)
+	}
+}
+
+// handleChannelUpdate publishes channels which are changed, e.g. renamed
+func (c *Service) handleChannelUpdate(s *discord.Session, ch *discord.ChannelUpdate) {
+	c.publishChannelEvent(ch.Channel, provider.ChannelEventUpserted)
+}
+
+// handleChannelCreate publishes channels which are created after the bot joined the server
+func (c *Service) handleChannelCreate(s *discord.Session, ch *discord.ChannelCreate) {
+	c.publishChannelEvent(ch.Channel, provider.ChannelEventRestored)
+}
+
+// handleChannelDelete publishes deleted channels, so their bots are removed
+func (c *Service) handleChannelDelete(s *discord.Session, ch *discord.ChannelDelete) {
+	c.publishChannelEvent(ch.Channel, provider.ChannelEventDeleted)
+}
+
+func (c *Service) publishChannelEvent(channel *discord.Channel, typ string) {
+	if channel == nil || !guildEnabled(channel.GuildID) || !channelEnabled(channel.Type) {
+		return
+	}
+	_, err := provider.ChannelTopic.Publish(context.Background(), &provider.ChannelEvent{
+		Provider: chatdb.ProviderDiscord,
+		Type:     typ,
+		Channels: []provider.ChannelInfo{toChannelInfo(channel)},
+	})
+	if err != nil {
+		rlog.Error("error publishing channel event", "channel", channel.ID, "type", typ, "error", err)
+	}
+}
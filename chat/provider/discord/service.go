@@ -11,27 +11,47 @@ import (
 	"image/png"
 	"os"
 	"os/signal"
+	"slices"
 	"strings"
 	"syscall"
+	"time"
 
 	discord "github.com/bwmarrin/discordgo"
 	"github.com/cockroachdb/errors"
 	"github.com/nfnt/resize"
 
-	botsvc "encore.app/bot"
 	botdb "encore.app/bot/db"
 	"encore.app/chat/provider"
 	"encore.app/chat/provider/discord/db"
 	chatdb "encore.app/chat/service/db"
+	"encore.dev/config"
 	"encore.dev/rlog"
 	"encore.dev/storage/sqldb"
 )
 
+// maxHistoryPageSize is the maximum number of messages Discord returns per request
+const maxHistoryPageSize = 100
+
 // This uses Encore's declarative database , learn more: https://encore.dev/docs/primitives/databases
 var discorddb = sqldb.NewDatabase("discord", sqldb.DatabaseConfig{
 	Migrations: "./db/migrations",
 })
 
+type Config struct {
+	// ManagerRoles are the names of the server roles which are allowed to manage bots with the /bot command
+	ManagerRoles config.Values[string]
+	// Guilds are the IDs of the servers whose channels are used. All servers are used if it's empty.
+	Guilds config.Values[string]
+	// ChannelTypes are the types of channels which are used, see channelTypes for the supported types
+	ChannelTypes config.Values[string]
+	// ExtractAttachmentText decides if the text of plain text and markdown files is downloaded and included in the
+	// chat history
+	ExtractAttachmentText config.Bool
+}
+
+// This uses Encore Configuration, learn more: https://encore.dev/docs/develop/config
+var cfg = config.Load[*Config]()
+
 // This uses Encore's built-in secrets manager, learn more: https://encore.dev/docs/primitives/secrets
 var secrets struct {
 	DiscordToken string
@@ -55,12 +75,15 @@ func initService() (*Service, error) {
 		return nil, err
 	}
 	svc := &Service{client: client}
-	err = svc.subscribeToMessages(context.Background(), func(ctx context.Context, msg *discord.MessageCreate) error {
-		message := toProviderMessage(msg.Message)
-		if message == nil {
-			return nil
-		}
-		_, err := provider.InboxTopic.Publish(ctx, message)
+	client.AddHandler(svc.registerCommands)
+	client.AddHandler(svc.handleCommand)
+	client.AddHandler(svc.handleGuildCreate)
+	client.AddHandler(svc.handleGuildDelete)
+	client.AddHandler(svc.handleChannelCreate)
+	client.AddHandler(svc.handleChannelUpdate)
+	client.AddHandler(svc.handleChannelDelete)
+	err = svc.subscribeToMessages(context.Background(), func(ctx context.Context, msg *provider.Message) error {
+		_, err := provider.InboxTopic.Publish(ctx, msg)
 		return errors.Wrap(err, "publish message")
 	})
 	if err != nil {
@@ -78,41 +101,72 @@ func (p *Service) Ping(ctx context.Context) error {
 	return nil
 }
 
-// ListChannels returns a list of channels in all the guilds the bot is a part of.
+// ListChannels returns a list of the channels of the configured types in the configured guilds the bot is a part of.
 //
 //encore:api private method=GET path=/discord/channels
 func (p *Service) ListChannels(ctx context.Context) (*provider.ListChannelsResponse, error) {
-	guilds, err := p.client.UserGuilds(100, "", "", false)
+	guilds, err := p.listGuilds(ctx)
 	if err != nil {
 		return nil, errors.Wrap(err, "error getting guilds")
 	}
 	var channelInfos []provider.ChannelInfo
 	for _, guild := range guilds {
-		channels, err := p.client.GuildChannels(guild.ID)
-		if err != nil {
-			return nil, errors.Wrap(err, "error getting guilds")
+		if !guildEnabled(guild.ID) {
+			continue
 		}
-		for _, channel := range channels {
-			channelInfos = append(channelInfos, provider.ChannelInfo{
-				Provider: chatdb.ProviderDiscord,
-				ID:       channel.ID,
-				Name:     channel.Name,
-			})
+		channels, err := p.client.GuildChannels(guild.ID, discord.WithContext(ctx))
+		if err != nil {
+			return nil, errors.Wrap(err, "error getting channels")
 		}
+		channelInfos = append(channelInfos, toChannelInfos(channels)...)
 	}
 	return &provider.ListChannelsResponse{Channels: channelInfos}, nil
 }
 
-// subscribeToMessages subscribes to messages from the Discord client and publishes them to the message topic.
-func (p *Service) subscribeToMessages(ctx context.Context, fn func(ctx context.Context, msg *discord.MessageCreate) error) error {
-	p.client.AddHandler(func(sess *discord.Session, msg *discord.MessageCreate) {
+// subscribeToMessages subscribes to new, edited and deleted messages from the Discord client and calls fn with
+// the converted provider messages.
+func (p *Service) subscribeToMessages(ctx context.Context, fn func(ctx context.Context, msg *provider.Message) error) error {
+	handle := func(msg *provider.Message) {
+		if msg == nil {
+			return
+		}
 		err := fn(ctx, msg)
 		if err != nil {
-			rlog.Error("error handling message: %v", err)
+			rlog.Error("error handling message", "error", err)
+		}
+	}
+	p.client.AddHandler(func(sess *discord.Session, msg *discord.MessageCreate) {
+		handle(p.toProviderMessage(msg.Message))
+	})
+	p.client.AddHandler(func(sess *discord.Session, msg *discord.MessageUpdate) {
+		// Updates are also sent when e.g. a thread is started from the message, skip them if neither the content
+		// changed nor links were embedded
+		links := toLinks(msg.Embeds)
+		if msg.Content == "" || (msg.BeforeUpdate != nil && msg.BeforeUpdate.Content == msg.Content && len(links) == 0) {
+			return
 		}
+		changed := p.toChangedMessage(provider.MessageTypeEdited, msg.ChannelID, msg.ID, msg.Content)
+		changed.Attachments = links
+		handle(changed)
+	})
+	p.client.AddHandler(func(sess *discord.Session, msg *discord.MessageDelete) {
+		handle(p.toChangedMessage(provider.MessageTypeDeleted, msg.ChannelID, msg.ID, ""))
+	})
+	p.client.AddHandler(func(sess *discord.Session, msg *discord.MessageDeleteBulk) {
+		for _, id := range msg.Messages {
+			handle(p.toChangedMessage(provider.MessageTypeDeleted, msg.ChannelID, id, ""))
+		}
+	})
+	p.client.AddHandler(func(sess *discord.Session, r *discord.MessageReactionAdd) {
+		handle(p.toReactionMessage(provider.MessageTypeReactionAdded, r.MessageReaction, r.Member))
+	})
+	p.client.AddHandler(func(sess *discord.Session, r *discord.MessageReactionRemove) {
+		handle(p.toReactionMessage(provider.MessageTypeReactionRemoved, r.MessageReaction, nil))
 	})
 
-	p.client.Identify.Intents = discord.IntentGuildMessages | discord.IntentMessageContent
+	// Guilds is required to keep track of channels and threads in the state cache
+	p.client.Identify.Intents = discord.IntentGuilds | discord.IntentGuildMessages | discord.IntentMessageContent |
+		discord.IntentGuildMessageReactions | discord.IntentDirectMessages | discord.IntentDirectMessageReactions
 
 	// Open the websocket and begin listening.
 	err := p.client.Open()
@@ -156,6 +210,10 @@ func (p *Service) GetUser(ctx context.Context, userID string) (*provider.User, e
 //
 //encore:api private method=POST path=/discord/channels/:channelID/leave
 func (c *Service) LeaveChannel(ctx context.Context, channelID string, bot *botdb.Bot) error {
+	// Bots don't have webhooks in direct messages
+	if c.dm(channelID) != nil {
+		return nil
+	}
 	q := db.New()
 	webhook, err := q.GetWebhookForBot(ctx, discorddb.Stdlib(), db.GetWebhookForBotParams{
 		Channel: channelID,
@@ -164,8 +222,9 @@ func (c *Service) LeaveChannel(ctx context.Context, channelID string, bot *botdb
 	if err != nil {
 		return errors.Wrap(err, "error getting webhook")
 	}
+	// The webhook might have been deleted in Discord already
 	err = c.client.WebhookDelete(webhook.ProviderID)
-	if err != nil {
+	if err != nil && !isDiscordError(err, discord.ErrCodeUnknownWebhook) {
 		return errors.Wrap(err, "error deleting webhook")
 	}
 	_, err = q.DeleteWebhook(ctx, discorddb.Stdlib(), webhook.ID)
@@ -200,49 +259,43 @@ func generateAvatarDataURI(data []byte) (string, error) {
 //
 //encore:api private method=POST path=/discord/channels/:channelID/join
 func (c *Service) JoinChannel(ctx context.Context, channelID string, bot *botdb.Bot) error {
-	var err error
-	avatarURI := ""
-	if avatar, err := botsvc.AvatarBlob(ctx, bot.ID); err == nil && avatar != nil {
-		avatarURI, err = generateAvatarDataURI(avatar.Avatar)
-		if err != nil {
-			return errors.Wrap(err, "error generating avatar data URI")
-		}
+	// Webhooks can't be created in direct messages, the bot talks through the main bot user instead
+	if c.dm(channelID) != nil {
+		return nil
 	}
-	q := db.New()
-	hook, err := q.GetWebhookForBot(ctx, discorddb.Stdlib(), db.GetWebhookForBotParams{
+	hook, err := db.New().GetWebhookForBot(ctx, discorddb.Stdlib(), db.GetWebhookForBotParams{
 		Channel: channelID,
 		BotID:   bot.ID,
 	})
 	if errors.Is(err, sql.ErrNoRows) {
-		hook, err := c.client.WebhookCreate(channelID, bot.Name, avatarURI)
-		if err != nil {
-			return errors.Wrap(err, "error creating webhook")
-		}
-		_, err = q.InsertWebhook(ctx, discorddb.Stdlib(), db.InsertWebhookParams{
-			ProviderID: hook.ID,
-			Channel:    hook.ChannelID,
-			Name:       hook.Name,
-			Token:      hook.Token,
-			BotID:      bot.ID,
-		})
-		if err != nil {
-			return errors.Wrap(err, "error inserting webhook")
-		}
-		return nil
+		_, err = c.createWebhook(ctx, channelID, bot)
+		return err
 	} else if err != nil {
 		return errors.Wrap(err, "error getting webhook")
 	}
+	avatarURI, err := avatarDataURI(ctx, bot)
+	if err != nil {
+		return err
+	}
 	_, err = c.client.WebhookEdit(hook.ProviderID, bot.Name, avatarURI, channelID)
+	if isDiscordError(err, discord.ErrCodeUnknownWebhook) {
+		_, err = c.recreateWebhook(ctx, hook, bot)
+		return err
+	}
 	if err != nil {
 		return errors.Wrap(err, "error editing webhook")
 	}
 	return nil
 }
 
-// SendMessage sends a message to a channel using the bot's webhook.
+// SendMessage sends a message to a channel using the bot's webhook. Direct messages are sent by the main bot user.
+// If the webhook was deleted in Discord, it's recreated and the message is sent again.
 //
 //encore:api private method=POST path=/discord/channels/:channelID/messages
 func (c *Service) SendMessage(ctx context.Context, channelID string, req *provider.SendMessageRequest) error {
+	if c.dm(channelID) != nil {
+		return c.sendDirectMessage(ctx, channelID, req)
+	}
 	webhook, err := db.New().GetWebhookForBot(ctx, discorddb.Stdlib(), db.GetWebhookForBotParams{
 		Channel: channelID,
 		BotID:   req.Bot.ID,
@@ -250,16 +303,60 @@ func (c *Service) SendMessage(ctx context.Context, channelID string, req *provid
 	if err != nil {
 		return errors.Wrap(err, "error getting webhook")
 	}
-	_, err = c.client.WebhookExecute(webhook.ProviderID, webhook.Token, false, &discord.WebhookParams{
-		Content:  req.Content,
+	params := &discord.WebhookParams{
+		Content:  provider.RenderAttachments(req.Content, req.Attachments),
 		Username: req.Bot.Name,
-	})
+	}
+	// Webhooks belong to the parent channel, but can post in any of its threads
+	_, err = c.client.WebhookThreadExecute(webhook.ProviderID, webhook.Token, false, req.ThreadID, params)
+	if isDiscordError(err, discord.ErrCodeUnknownWebhook) {
+		rlog.Warn("webhook was deleted, recreating it", "channel", channelID, "bot", req.Bot.Name)
+		webhook, err = c.recreateWebhook(ctx, webhook, req.Bot)
+		if err != nil {
+			return err
+		}
+		_, err = c.client.WebhookThreadExecute(webhook.ProviderID, webhook.Token, false, req.ThreadID, params)
+	}
 	return errors.Wrap(err, "error sending message")
 }
 
-// toProviderMessage converts a Discord message to the generic provider message.
-func toProviderMessage(msg *discord.Message) *provider.Message {
-	if msg.Content == "" || msg.Type != discord.MessageTypeDefault {
+// sendDirectMessage sends a message in a direct message channel using the main bot user. Messages of the bot user
+// are ignored when received, so the message is published to the inbox on behalf of the bot that sent it.
+func (c *Service) sendDirectMessage(ctx context.Context, channelID string, req *provider.SendMessageRequest) error {
+	content := provider.RenderAttachments(req.Content, req.Attachments)
+	msg, err := c.client.ChannelMessageSend(channelID, content)
+	if err != nil {
+		return errors.Wrap(err, "error sending direct message")
+	}
+	_, err = provider.InboxTopic.Publish(ctx, &provider.Message{
+		Provider:   chatdb.ProviderDiscord,
+		ProviderID: msg.ID,
+		ChannelID:  channelID,
+		Author:     c.dmAuthor(req.Bot),
+		Content:    content,
+		Time:       msg.Timestamp.UTC(),
+	})
+	return errors.Wrap(err, "publish message")
+}
+
+// dmAuthor returns the author of messages and reactions of a bot in direct messages
+func (c *Service) dmAuthor(bot *botdb.Bot) provider.User {
+	return provider.User{
+		ID:    c.client.State.User.ID + ":" + bot.Name,
+		Name:  bot.Name,
+		BotID: bot.ID,
+	}
+}
+
+// toProviderMessage converts a Discord message to the generic provider message. Messages in threads are
+// attributed to the thread's parent channel.
+func (c *Service) toProviderMessage(msg *discord.Message) *provider.Message {
+	if (msg.Content == "" && len(msg.Attachments) == 0) ||
+		(msg.Type != discord.MessageTypeDefault && msg.Type != discord.MessageTypeReply) {
+		return nil
+	}
+	// Messages of the bot user are direct messages, which are published by sendDirectMessage
+	if c.client.State.User != nil && msg.Author.ID == c.client.State.User.ID {
 		return nil
 	}
 	author := provider.User{
@@ -273,31 +370,149 @@ func toProviderMessage(msg *discord.Message) *provider.Message {
 			author.BotID = hook.BotID
 		}
 	}
-	return &provider.Message{
+	rtn := &provider.Message{
+		Provider:    chatdb.ProviderDiscord,
+		ProviderID:  msg.ID,
+		ChannelID:   msg.ChannelID,
+		Author:      author,
+		Content:     msg.Content,
+		Time:        msg.Timestamp.UTC(),
+		Attachments: toAttachments(context.Background(), msg),
+	}
+	if msg.MessageReference != nil {
+		rtn.ParentID = msg.MessageReference.MessageID
+	}
+	if thread := c.thread(msg.ChannelID); thread != nil {
+		// Threads started from a message share the ID of that message, so the thread ID
+		// also references the first message of the thread.
+		rtn.ChannelID = thread.ParentID
+		rtn.ThreadID = thread.ID
+		if rtn.ParentID == "" {
+			rtn.ParentID = thread.ID
+		}
+	}
+	return rtn
+}
+
+// toChangedMessage creates a provider message for an edited or deleted Discord message.
+func (c *Service) toChangedMessage(typ, channelID, messageID, content string) *provider.Message {
+	rtn := &provider.Message{
 		Provider:   chatdb.ProviderDiscord,
-		ProviderID: msg.ID,
-		ChannelID:  msg.ChannelID,
-		Author:     author,
-		Content:    msg.Content,
-		Time:       msg.Timestamp.UTC(),
+		ProviderID: messageID,
+		ChannelID:  channelID,
+		Content:    content,
+		Time:       time.Now().UTC(),
+		Type:       typ,
+	}
+	if thread := c.thread(channelID); thread != nil {
+		rtn.ChannelID = thread.ParentID
+		rtn.ThreadID = thread.ID
+	}
+	return rtn
+}
+
+// toReactionMessage creates a provider message for a reaction that was added or removed. Reactions added by the
+// Discord bot user are ignored, as they are published by React on behalf of the bot that reacted.
+func (c *Service) toReactionMessage(typ string, r *discord.MessageReaction, member *discord.Member) *provider.Message {
+	if c.client.State.User != nil && r.UserID == c.client.State.User.ID {
+		return nil
+	}
+	emoji := r.Emoji.Name
+	if r.Emoji.ID != "" {
+		// Custom emojis don't have a unicode representation, so we use their name
+		emoji = ":" + r.Emoji.Name + ":"
+	}
+	author := provider.User{ID: r.UserID}
+	if member != nil && member.User != nil {
+		author.Name = member.User.Username
+	}
+	rtn := &provider.Message{
+		Provider:  chatdb.ProviderDiscord,
+		ChannelID: r.ChannelID,
+		Author:    author,
+		Content:   emoji,
+		Time:      time.Now().UTC(),
+		Type:      typ,
+		ParentID:  r.MessageID,
+	}
+	if thread := c.thread(r.ChannelID); thread != nil {
+		rtn.ChannelID = thread.ParentID
+		rtn.ThreadID = thread.ID
+	}
+	return rtn
+}
+
+// thread returns the thread channel with the given ID, or nil if the channel is not a thread.
+func (c *Service) thread(channelID string) *discord.Channel {
+	channel := c.channel(channelID)
+	if channel == nil || !channel.IsThread() {
+		return nil
+	}
+	return channel
+}
+
+// dm returns the direct message channel with the given ID, or nil if the channel is not a direct message channel.
+func (c *Service) dm(channelID string) *discord.Channel {
+	channel := c.channel(channelID)
+	if channel == nil || channel.Type != discord.ChannelTypeDM {
+		return nil
 	}
+	return channel
 }
 
-// ListMessages returns a list of messages in a channel.
+// channel returns the channel with the given ID from the state cache, or fetches it if it's not cached.
+func (c *Service) channel(channelID string) *discord.Channel {
+	channel, err := c.client.State.Channel(channelID)
+	if err != nil {
+		channel, err = c.client.Channel(channelID)
+		if err != nil {
+			rlog.Warn("error getting channel", "channel", channelID, "error", err)
+			return nil
+		}
+		// Cache the channel to avoid fetching it for every message
+		_ = c.client.State.ChannelAdd(channel)
+	}
+	return channel
+}
+
+// ListMessages returns a page of messages in a channel. The cursor is the ID of the oldest message of the previous
+// page. Rate limited requests are retried by the Discord client.
 //
 //encore:api private method=GET path=/discord/channels/:channelID/messages
 func (c *Service) ListMessages(ctx context.Context, channelID string, req *provider.ListMessagesRequest) (*provider.ListMessagesResponse, error) {
-	msgs, err := c.client.ChannelMessages(channelID, 100, "", req.FromMessageID, "")
+	limit := req.Limit
+	if limit <= 0 || limit > maxHistoryPageSize {
+		limit = maxHistoryPageSize
+	}
+	msgs, err := c.client.ChannelMessages(channelID, limit, req.Cursor, "", "", discord.WithContext(ctx))
 	if err != nil {
 		return nil, errors.Wrap(err, "error getting messages")
 	}
-	var messages []*provider.Message
-	for i := len(msgs) - 1; i >= 0; i-- {
-		if msg := toProviderMessage(msgs[i]); msg != nil {
-			messages = append(messages, msg)
+	rtn := &provider.ListMessagesResponse{}
+	// Messages are returned newest first
+	for i, msg := range msgs {
+		if req.After != "" && !newerThan(msg.ID, req.After) {
+			msgs = msgs[:i]
+			break
+		}
+		if pm := c.toProviderMessage(msg); pm != nil {
+			rtn.Messages = append(rtn.Messages, pm)
 		}
 	}
-	return &provider.ListMessagesResponse{Messages: messages}, nil
+	slices.Reverse(rtn.Messages)
+	if len(msgs) == limit {
+		rtn.NextCursor = msgs[len(msgs)-1].ID
+	}
+	return rtn, nil
+}
+
+// newerThan returns true if the message with ID a was created after the message with ID b. Discord IDs are
+// snowflakes which start with a timestamp, so they are ordered by creation time.
+func newerThan(a, b string) bool {
+	if len(a) != len(b) {
+		return len(a) > len(b)
+	}
+	return a > b
 }
 
 // ChannelInfo returns information about a channel.
@@ -308,9 +523,50 @@ func (c *Service) ChannelInfo(ctx context.Context, channelID string) (provider.C
 	if err != nil {
 		return provider.ChannelInfo{}, errors.Wrap(err, "error getting channel info")
 	}
-	return provider.ChannelInfo{
-		Provider: chatdb.ProviderDiscord,
-		ID:       provider.ChannelID(resp.ID),
-		Name:     resp.Name,
-	}, nil
+	info := toChannelInfo(resp)
+	// Direct message channels don't have a name, so they are named after the user
+	if resp.Type == discord.ChannelTypeDM && len(resp.Recipients) > 0 {
+		info.DMUser = resp.Recipients[0].ID
+		info.Name = "dm-" + resp.Recipients[0].Username
+	}
+	return info, nil
+}
+
+// React adds a reaction to a message. Webhooks can't react to messages, so the reaction is added by the Discord bot
+// user and published to the inbox on behalf of the bot that reacted.
+//
+//encore:api private method=POST path=/discord/channels/:channelID/reactions
+func (c *Service) React(ctx context.Context, channelID string, req *provider.ReactRequest) error {
+	author := c.dmAuthor(req.Bot)
+	if c.dm(channelID) == nil {
+		webhook, err := db.New().GetWebhookForBot(ctx, discorddb.Stdlib(), db.GetWebhookForBotParams{
+			Channel: channelID,
+			BotID:   req.Bot.ID,
+		})
+		if err != nil {
+			return errors.Wrap(err, "error getting webhook")
+		}
+		// Use the same author ID as messages sent by the bot's webhook
+		author.ID = webhook.ProviderID + ":" + req.Bot.Name
+	}
+	// Messages in threads are reacted to in the thread channel
+	target := channelID
+	if req.ThreadID != "" {
+		target = req.ThreadID
+	}
+	err := c.client.MessageReactionAdd(target, req.MessageID, req.Emoji)
+	if err != nil {
+		return errors.Wrap(err, "error adding reaction")
+	}
+	_, err = provider.InboxTopic.Publish(ctx, &provider.Message{
+		Provider:  chatdb.ProviderDiscord,
+		ChannelID: channelID,
+		Author:    author,
+		Content:   req.Emoji,
+		Time:      time.Now().UTC(),
+		Type:      provider.MessageTypeReactionAdded,
+		ThreadID:  req.ThreadID,
+		ParentID:  req.MessageID,
+	})
+	return errors.Wrap(err, "publish reaction")
 }
@@ -0,0 +1,204 @@
+package discord
+
+import (
+	"context"
+
+	discord "github.com/bwmarrin/discordgo"
+	"github.com/cockroachdb/errors"
+
+	botsvc "encore.app/bot"
+	botdb "encore.app/bot/db"
+	"encore.app/chat/provider/discord/db"
+	"encore.dev/cron"
+	"encore.dev/rlog"
+	"encore.dev/types/uuid"
+)
+
+// This cron job reconciles the webhooks in the database with the webhooks in Discord
+//
+// This uses Encore's cron feature, learn more: https://encore.dev/docs/primitives/cron-jobs
+var _ = cron.NewJob("reconcile-webhooks", cron.JobConfig{
+	Title:    "Reconcile Discord Webhooks",
+	Every:    1 * cron.Hour,
+	Endpoint: ReconcileWebhooks,
+})
+
+// ReconcileWebhooks brings the webhooks in the database in line with Discord. Webhooks deleted in Discord are
+// recreated, webhooks of deleted channels and bots are pruned, and webhooks of the app which aren't in the database
+// are deleted from Discord. Guilds which are disabled or whose webhooks can't be listed are left alone.
+//
+//encore:api private
+func (c *Service) ReconcileWebhooks(ctx context.Context) error {
+	if c == nil {
+		return nil
+	}
+	q := db.New()
+	hooks, err := q.ListWebhooks(ctx, discorddb.Stdlib())
+	if err != nil {
+		return errors.Wrap(err, "error listing webhooks")
+	}
+	remote, unlisted, err := c.listAppWebhooks(ctx)
+	if err != nil {
+		return err
+	}
+	bots, err := c.listBots(ctx, hooks)
+	if err != nil {
+		return err
+	}
+	known := make(map[string]bool, len(hooks))
+	for _, hook := range hooks {
+		known[hook.ProviderID] = true
+		bot, ok := bots[hook.BotID]
+		_, exists := remote[hook.ProviderID]
+		switch {
+		case !ok:
+			rlog.Info("pruning webhook of deleted bot", "webhook", hook.ProviderID, "channel", hook.Channel)
+			if exists {
+				err = c.client.WebhookDelete(hook.ProviderID, discord.WithContext(ctx))
+				if err != nil && !isDiscordError(err, discord.ErrCodeUnknownWebhook) {
+					return errors.Wrap(err, "error deleting webhook")
+				}
+			}
+			_, err = q.DeleteWebhook(ctx, discorddb.Stdlib(), hook.ID)
+		case exists:
+			continue
+		case len(unlisted) > 0 && unlisted[c.channelGuild(ctx, hook.Channel)]:
+			// It's unknown if the webhook still exists, so it's left alone
+			continue
+		case c.channelDeleted(ctx, hook.Channel):
+			rlog.Info("pruning webhook of deleted channel", "webhook", hook.ProviderID, "channel", hook.Channel)
+			_, err = q.DeleteWebhook(ctx, discorddb.Stdlib(), hook.ID)
+		default:
+			rlog.Info("recreating deleted webhook", "channel", hook.Channel, "bot", bot.Name)
+			_, err = c.recreateWebhook(ctx, hook, bot)
+		}
+		if err != nil {
+			return errors.Wrap(err, "error reconciling webhook")
+		}
+	}
+	for id := range remote {
+		if known[id] {
+			continue
+		}
+		rlog.Info("deleting orphaned webhook", "webhook", id)
+		err = c.client.WebhookDelete(id, discord.WithContext(ctx))
+		if err != nil && !isDiscordError(err, discord.ErrCodeUnknownWebhook) {
+			return errors.Wrap(err, "error deleting webhook")
+		}
+	}
+	return nil
+}
+
+// listAppWebhooks returns the webhooks created by the app in the enabled guilds the bot is a part of, by ID. Guilds
+// which are disabled or whose webhooks can't be listed, e.g. because the bot lacks the Manage Webhooks permission,
+// are skipped and returned as unlisted.
+func (c *Service) listAppWebhooks(ctx context.Context) (map[string]*discord.Webhook, map[string]bool, error) {
+	guilds, err := c.listGuilds(ctx)
+	if err != nil {
+		return nil, nil, err
+	}
+	rtn := make(map[string]*discord.Webhook)
+	unlisted := make(map[string]bool)
+	for _, guild := range guilds {
+		if !guildEnabled(guild.ID) {
+			unlisted[guild.ID] = true
+			continue
+		}
+		hooks, err := c.client.GuildWebhooks(guild.ID, discord.WithContext(ctx))
+		if err != nil {
+			rlog.Warn("skipping webhooks of guild", "guild", guild.ID, "error", err)
+			unlisted[guild.ID] = true
+			continue
+		}
+		for _, hook := range hooks {
+			if hook.Type == discord.WebhookTypeIncoming && hook.User != nil && hook.User.ID == c.client.State.User.ID {
+				rtn[hook.ID] = hook
+			}
+		}
+	}
+	return rtn, unlisted, nil
+}
+
+// listBots returns the bots of the webhooks which haven't been deleted, by ID.
+func (c *Service) listBots(ctx context.Context, hooks []*db.Webhook) (map[uuid.UUID]*botdb.Bot, error) {
+	rtn := make(map[uuid.UUID]*botdb.Bot)
+	if len(hooks) == 0 {
+		return rtn, nil
+	}
+	ids := make([]uuid.UUID, 0, len(hooks))
+	for _, hook := range hooks {
+		ids = append(ids, hook.BotID)
+	}
+	resp, err := botsvc.List(ctx, &botsvc.ListBotRequest{IDs: ids})
+	if err != nil {
+		return nil, errors.Wrap(err, "error listing bots")
+	}
+	for _, bot := range resp.Bots {
+		rtn[bot.ID] = bot
+	}
+	return rtn, nil
+}
+
+// channelDeleted returns true if Discord reports that the channel doesn't exist anymore, or that the bot can't
+// access it anymore, e.g. because it was removed from the guild.
+func (c *Service) channelDeleted(ctx context.Context, channelID string) bool {
+	_, err := c.client.Channel(channelID, discord.WithContext(ctx))
+	return isDiscordError(err, discord.ErrCodeUnknownChannel) || isDiscordError(err, discord.ErrCodeMissingAccess)
+}
+
+// channelGuild returns the ID of the guild of a channel, or an empty string if the channel can't be found.
+func (c *Service) channelGuild(ctx context.Context, channelID string) string {
+	if channel, err := c.client.State.Channel(channelID); err == nil {
+		return channel.GuildID
+	}
+	channel, err := c.client.Channel(channelID, discord.WithContext(ctx))
+	if err != nil {
+		return ""
+	}
+	return channel.GuildID
+}
+
+// createWebhook creates a webhook for the bot in the channel and stores it in the database.
+func (c *Service) createWebhook(ctx context.Context, channelID string, bot *botdb.Bot) (*db.Webhook, error) {
+	avatarURI, err := avatarDataURI(ctx, bot)
+	if err != nil {
+		return nil, err
+	}
+	hook, err := c.client.WebhookCreate(channelID, bot.Name, avatarURI, discord.WithContext(ctx))
+	if err != nil {
+		return nil, errors.Wrap(err, "error creating webhook")
+	}
+	webhook, err := db.New().InsertWebhook(ctx, discorddb.Stdlib(), db.InsertWebhookParams{
+		ProviderID: hook.ID,
+		Channel:    hook.ChannelID,
+		Name:       hook.Name,
+		Token:      hook.Token,
+		BotID:      bot.ID,
+	})
+	return webhook, errors.Wrap(err, "error inserting webhook")
+}
+
+// recreateWebhook replaces a webhook which was deleted in Discord with a new one.
+func (c *Service) recreateWebhook(ctx context.Context, hook *db.Webhook, bot *botdb.Bot) (*db.Webhook, error) {
+	_, err := db.New().DeleteWebhook(ctx, discorddb.Stdlib(), hook.ID)
+	if err != nil {
+		return nil, errors.Wrap(err, "error deleting webhook")
+	}
+	return c.createWebhook(ctx, hook.Channel, bot)
+}
+
+// avatarDataURI returns the avatar of the bot as a data URI, or an empty string if the bot doesn't have an avatar.
+func avatarDataURI(ctx context.Context, bot *botdb.Bot) (string, error) {
+	avatar, err := botsvc.AvatarBlob(ctx, bot.ID)
+	if err != nil || avatar == nil {
+		return "", nil
+	}
+	uri, err := generateAvatarDataURI(avatar.Avatar)
+	return uri, errors.Wrap(err, "error generating avatar data URI")
+}
+
+// isDiscordError returns true if err is a Discord API error with the given JSON error code.
+func isDiscordError(err error, code int) bool {
+	var restErr *discord.RESTError
+	return errors.As(err, &restErr) && restErr.Message != nil && restErr.Message.Code == code
+}
@@ -46,6 +46,19 @@ type ClientMessage struct {
 	Timestamp time.Time   `json:"timestamp"`
 	Client    *Client     `json:"-"`
 	Bots      []uuid.UUID `json:"bots"`
+	// MessageId is the ID of the message a reaction refers to
+	MessageId string `json:"messageId"`
+	// Attachments are the files uploaded with a message
+	Attachments []Attachment `json:"attachments,omitempty"`
+}
+
+// Attachment is a file uploaded to the local chat. The file itself isn't stored, only its metadata and text.
+type Attachment struct {
+	Type     string `json:"type"`
+	Name     string `json:"name"`
+	MimeType string `json:"mimeType"`
+	Size     int64  `json:"size"`
+	Text     string `json:"text,omitempty"`
 }
 
 // Client is a middleman between the websocket connection and the svc.
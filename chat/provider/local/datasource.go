@@ -65,7 +65,7 @@ func (d *DataSource) GetChannelMessages(ctx context.Context, c *db.Channel) ([]*
 		return nil, errors.Wrap(err, "list messages")
 	}
 	slices.Reverse(messages)
-	return messages, nil
+	return db.ToMessages(messages), nil
 }
 
 func (d *DataSource) GetChannelMessagesAfter(ctx context.Context, c *db.Channel, providerMsgID string) ([]*db.Message, error) {
@@ -79,5 +79,14 @@ func (d *DataSource) GetChannelMessagesAfter(ctx context.Context, c *db.Channel,
 		return nil, errors.Wrap(err, "list messages")
 	}
 	slices.Reverse(messages)
-	return messages, nil
+	return db.ToMessages(messages), nil
+}
+
+func (d *DataSource) GetChannelReactions(ctx context.Context, c *db.Channel) ([]*db.Reaction, error) {
+	q := db.New()
+	reactions, err := q.ListReactionsInChannel(ctx, chatDb.Stdlib(), c.ID)
+	if err != nil {
+		return nil, errors.Wrap(err, "list reactions")
+	}
+	return reactions, nil
 }
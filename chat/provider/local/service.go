@@ -17,6 +17,7 @@ import (
 	"encore.app/chat/provider/local/chat"
 	chatdb "encore.app/chat/service/db"
 	"encore.app/pkg/fns"
+	"encore.dev"
 	"encore.dev/config"
 	"encore.dev/rlog"
 	"encore.dev/types/uuid"
@@ -208,20 +209,47 @@ func (s *Service) sendChannelInfo(ctx context.Context, channelID string, channel
 			return errors.Wrap(err, "get channel messages")
 		}
 		usersByID := fns.ToMap(users, func(user *chatdb.User) uuid.UUID { return user.ID })
+		userID := func(id uuid.UUID) string {
+			if user, ok := usersByID[id]; ok {
+				return user.ProviderID
+			}
+			return "Unknown"
+		}
+		// Clients know messages by their provider ID, it's also what they send back when reconnecting
+		msgIDs := make(map[uuid.UUID]string, len(msgs))
 		for _, msg := range msgs {
-			userID := "Unknown"
-			if user, ok := usersByID[msg.AuthorID]; ok {
-				userID = user.ProviderID
+			msgIDs[msg.ID] = msg.ProviderID
+			if msg.ProviderID == "" {
+				msgIDs[msg.ID] = msg.ID.String()
 			}
 			client.SendMessage(&chat.ClientMessage{
-				ID:             msg.ID.String(),
+				ID:             msgIDs[msg.ID],
 				Type:           "message",
-				UserId:         userID,
+				UserId:         userID(msg.AuthorID),
 				ConversationId: channelID,
 				Content:        msg.Content,
 				Timestamp:      msg.Timestamp,
 			})
 		}
+		reactions, err := s.data.GetChannelReactions(ctx, channel)
+		if err != nil {
+			return errors.Wrap(err, "get channel reactions")
+		}
+		for _, r := range reactions {
+			msgID, ok := msgIDs[r.MessageID]
+			if !ok {
+				continue
+			}
+			client.SendMessage(&chat.ClientMessage{
+				ID:             r.ID.String(),
+				Type:           "reaction",
+				UserId:         userID(r.UserID),
+				ConversationId: channelID,
+				Content:        r.Emoji,
+				MessageId:      msgID,
+				Timestamp:      r.Timestamp,
+			})
+		}
 		return nil
 	}
 }
@@ -237,14 +265,14 @@ func (s *Service) handleClientMessage(ctx context.Context, clientMsg *chat.Clien
 		return nil
 	} else if clientMsg.Type == "join" && clientMsg.Client != nil {
 		return s.sendChannelInfo(ctx, clientMsg.ConversationId, clientMsg.ConversationName, "", clientMsg.Client, clientMsg.Bots...)
-	} else if clientMsg.Type != "message" {
+	} else if clientMsg.Type != "message" && clientMsg.Type != "reaction" {
 		return nil
 	}
 	var botID uuid.UUID
 	if id, ok := strings.CutPrefix(clientMsg.UserId, "b-"); ok {
 		botID, _ = uuid.FromString(id)
 	}
-	_, err := provider.InboxTopic.Publish(ctx, &provider.Message{
+	msg := &provider.Message{
 		Provider:   chatdb.ProviderLocalchat,
 		ProviderID: clientMsg.ID,
 		ChannelID:  clientMsg.ConversationId,
@@ -256,10 +284,72 @@ func (s *Service) handleClientMessage(ctx context.Context, clientMsg *chat.Clien
 		Content: clientMsg.Content,
 		Time:    time.Now(),
 		Type:    clientMsg.Type,
-	})
+		Attachments: fns.Map(clientMsg.Attachments, func(a chat.Attachment) provider.Attachment {
+			return provider.Attachment{
+				Type:     a.Type,
+				Name:     a.Name,
+				MimeType: a.MimeType,
+				Size:     a.Size,
+				Text:     a.Text,
+			}
+		}),
+	}
+	if clientMsg.Type == "reaction" {
+		msg.Type = provider.MessageTypeReactionAdded
+		msg.ParentID = clientMsg.MessageId
+	}
+	_, err := provider.InboxTopic.Publish(ctx, msg)
 	return errors.Wrap(err, "publish message")
 }
 
+// maxUploadSize is the maximum size of files uploaded to the local chat
+const maxUploadSize = 32 << 20
+
+// Upload posts a message with a file to a channel. It expects a multipart form with the file, the userId of the
+// author and an optional content. The file isn't stored, only its name, size and the text of text files are.
+//
+//encore:api public raw method=POST path=/localchat/channels/:channelID/uploads
+func (s *Service) Upload(w http.ResponseWriter, r *http.Request) {
+	if !cfg.Enabled() {
+		http.Error(w, "not enabled", http.StatusNotFound)
+		return
+	}
+	r.Body = http.MaxBytesReader(w, r.Body, maxUploadSize)
+	file, header, err := r.FormFile("file")
+	if err != nil {
+		http.Error(w, "a file is required", http.StatusBadRequest)
+		return
+	}
+	defer file.Close()
+	userID := r.FormValue("userId")
+	if userID == "" {
+		http.Error(w, "a userId is required", http.StatusBadRequest)
+		return
+	}
+	att := provider.NewFileAttachment(header.Filename, "", header.Header.Get("Content-Type"), header.Size)
+	if att.HasText() {
+		if err := att.ReadText(file); err != nil {
+			rlog.Warn("read uploaded file", "file", header.Filename, "error", err)
+		}
+	}
+	s.hub.BroadCast(r.Context(), &chat.ClientMessage{
+		ID:             uuid.Must(uuid.NewV4()).String(),
+		Type:           "message",
+		UserId:         userID,
+		ConversationId: encore.CurrentRequest().PathParams.Get("channelID"),
+		Content:        r.FormValue("content"),
+		Timestamp:      time.Now(),
+		Attachments: []chat.Attachment{{
+			Type:     att.Type,
+			Name:     att.Name,
+			MimeType: att.MimeType,
+			Size:     att.Size,
+			Text:     att.Text,
+		}},
+	})
+	w.WriteHeader(http.StatusNoContent)
+}
+
 // SendTyping broadcasts a typing message to all clients in a channel.
 //
 //encore:api private method=POST path=/localchat/channels/:channelID/bots/:botID
@@ -284,7 +374,26 @@ func (s *Service) SendMessage(ctx context.Context, channelID string, req *provid
 		Type:           req.Type,
 		UserId:         "b-" + req.Bot.ID.String(),
 		ConversationId: channelID,
-		Content:        req.Content,
+		Content:        provider.RenderAttachments(req.Content, req.Attachments),
+		Timestamp:      time.Now(),
+	})
+	return nil
+}
+
+// React broadcasts a reaction from a bot to all connected clients in a channel.
+//
+//encore:api private method=POST path=/localchat/channels/:channelID/reactions
+func (s *Service) React(ctx context.Context, channelID string, req *provider.ReactRequest) error {
+	if req.Bot == nil {
+		return errors.New("only bots can react")
+	}
+	s.hub.BroadCast(ctx, &chat.ClientMessage{
+		ID:             uuid.Must(uuid.NewV4()).String(),
+		Type:           "reaction",
+		UserId:         "b-" + req.Bot.ID.String(),
+		ConversationId: channelID,
+		Content:        req.Emoji,
+		MessageId:      req.MessageID,
 		Timestamp:      time.Now(),
 	})
 	return nil
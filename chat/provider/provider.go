@@ -18,17 +18,56 @@ type SendMessageRequest struct {
 	Bot     *db.Bot
 	UserID  string
 	Type    string
+	// ThreadID is the provider ID of the thread to reply in. Empty for top level messages.
+	ThreadID string
+	// Attachments are sent along with the content. Providers which can't upload files render them as text.
+	Attachments []Attachment
 }
 
+// ReactRequest is a request for a bot to react to a message with an emoji
+type ReactRequest struct {
+	// MessageID is the provider ID of the message to react to
+	MessageID string
+	// ThreadID is the provider ID of the thread the message is in, if any
+	ThreadID string
+	Emoji    string
+	Bot      *db.Bot
+}
+
+// ListMessagesResponse is a page of the message history of a channel
 type ListMessagesResponse struct {
+	// Messages in the page, oldest first
 	Messages []*Message
+	// NextCursor is the cursor of the next, older, page. It's empty if there are no more messages.
+	NextCursor string
 }
 
+// ListMessagesRequest requests a page of the message history of a channel. Pages are returned newest first, the
+// next page is requested with the NextCursor of the previous one. Cursors are provider IDs of messages, so they
+// can be stored to resume loading the history later.
 type ListMessagesRequest struct {
-	FromTimestamp string
-	FromMessageID string
+	// After is the provider ID of a message, only messages posted after it are returned
+	After string
+	// Cursor is the NextCursor of the previous page, it's empty for the newest page
+	Cursor string
+	// Limit is the maximum number of messages in the page. Providers cap it to their maximum page size.
+	Limit int
 }
 
+// Message types of events that modify an existing message. The ProviderID of the message identifies the message
+// that was changed.
+const (
+	MessageTypeEdited  = "message_edited"
+	MessageTypeDeleted = "message_deleted"
+)
+
+// Message types of reaction events. The Content of the message is the emoji and the ParentID is the provider ID
+// of the message that was reacted to.
+const (
+	MessageTypeReactionAdded   = "reaction_added"
+	MessageTypeReactionRemoved = "reaction_removed"
+)
+
 type UserID = string
 
 type ChannelID = string
@@ -43,6 +82,13 @@ type Message struct {
 	Time       time.Time
 	Type       string
 	Bots       []uuid.UUID
+	// ThreadID is the provider ID of the thread the message was posted in. It's empty for top level messages.
+	ThreadID string
+	// ParentID is the provider ID of the message this message replies to, if any.
+	ParentID string
+	// Attachments are the files, links and code snippets attached to the message. For edited messages, they are
+	// the links unfurled after the message was posted.
+	Attachments []Attachment
 }
 
 // User is a user in a provider
@@ -58,4 +104,66 @@ type ChannelInfo struct {
 	Provider db2.Provider
 	ID       ChannelID
 	Name     string
+	// DMUser is the ID of the user in a direct message channel. It's empty for all other channels.
+	DMUser UserID
+	// GuildID is the ID of the server the channel belongs to, for providers with servers
+	GuildID string
+}
+
+// Types of channel events
+const (
+	// ChannelEventUpserted is published when channels are discovered or changed, e.g. when they're renamed. It
+	// doesn't restore archived or deleted channels.
+	ChannelEventUpserted = "upserted"
+	// ChannelEventRestored is published when channels are created or unarchived, or the bot joins a server. Channels
+	// which were archived or deleted before are restored.
+	ChannelEventRestored = "restored"
+	// ChannelEventGuildRemoved is published when the bot leaves a server, all channels of the server are removed
+	ChannelEventGuildRemoved = "guild_removed"
+	// ChannelEventArchived is published when channels are archived. They're restored by a restored event when
+	// they're unarchived, but their bots have left.
+	ChannelEventArchived = "archived"
+	// ChannelEventDeleted is published when channels are deleted
+	ChannelEventDeleted = "deleted"
+)
+
+// ChannelEvent is a change to the channels of a provider
+type ChannelEvent struct {
+	Provider db2.Provider
+	Type     string
+	// Channels are the upserted, restored, archived or deleted channels
+	Channels []ChannelInfo
+	// GuildID is the ID of the removed server
+	GuildID string
+}
+
+// Commands to manage bots in a channel
+const (
+	CommandAddBot    = "add"
+	CommandRemoveBot = "remove"
+	CommandListBots  = "list"
+	CommandInstruct  = "instruct"
+	CommandCreateBot = "create"
+)
+
+// Command is a bot management command issued by a user in a provider channel
+type Command struct {
+	Provider  db2.Provider
+	ChannelID ChannelID
+	User      User
+	Name      string
+	// Bot is the name of the bot the command applies to
+	Bot string
+	// Text is the instruction for instruct commands and the prompt for create commands
+	Text string
+	// LLM is the LLM provider of bots created with create commands
+	LLM string
+	// Token identifies the command in the provider. It's used to respond to the user who issued the command.
+	Token string
+}
+
+// CommandResponse is the response to a command, it's only visible to the user who issued the command
+type CommandResponse struct {
+	Token   string
+	Content string
 }
@@ -10,3 +10,18 @@ import (
 var InboxTopic = pubsub.NewTopic[*Message]("inbox", pubsub.TopicConfig{
 	DeliveryGuarantee: pubsub.AtLeastOnce,
 })
+
+// CommandTopic is the pubsub topic for bot management commands issued by users in chat providers, e.g. slash
+// commands. The providers check the permissions of the user before publishing a command.
+//
+// This uses Encore's pubsub package, learn more: https://encore.dev/docs/primitives/pubsub
+var CommandTopic = pubsub.NewTopic[*Command]("command", pubsub.TopicConfig{
+	DeliveryGuarantee: pubsub.AtLeastOnce,
+})
+
+// ChannelTopic is the pubsub topic for changes to the channels of chat providers
+//
+// This uses Encore's pubsub package, learn more: https://encore.dev/docs/primitives/pubsub
+var ChannelTopic = pubsub.NewTopic[*ChannelEvent]("channel", pubsub.TopicConfig{
+	DeliveryGuarantee: pubsub.AtLeastOnce,
+})
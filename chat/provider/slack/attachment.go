@@ -0,0 +1,63 @@
+package slack
+
+import (
+	"context"
+	"net/http"
+
+	"github.com/slack-go/slack"
+
+	"encore.app/chat/provider"
+	"encore.dev/rlog"
+)
+
+// toAttachments converts the files and unfurled links of a message to provider attachments. The text of text files
+// is downloaded if ExtractAttachmentText is enabled.
+func toAttachments(ctx context.Context, msg slack.Msg) []provider.Attachment {
+	var rtn []provider.Attachment
+	for _, f := range msg.Files {
+		name := f.Name
+		if name == "" {
+			name = f.Title
+		}
+		att := provider.NewFileAttachment(name, f.URLPrivate, f.Mimetype, int64(f.Size))
+		// Snippets are shared as text files, but their file type is the language they're written in
+		if f.Mode == "snippet" {
+			att.Type = provider.AttachmentTypeCode
+		}
+		if cfg.ExtractAttachmentText() {
+			// Files are private to the workspace, so they're downloaded with the app's token
+			header := http.Header{"Authorization": {"Bearer " + secrets.SlackToken}}
+			if err := att.FetchText(ctx, header); err != nil {
+				rlog.Warn("error fetching attachment text", "file", f.ID, "error", err)
+			}
+		}
+		rtn = append(rtn, att)
+	}
+	return append(rtn, toLinks(msg.Attachments)...)
+}
+
+// toLinks converts the unfurled links of a message to link attachments. Legacy attachments posted by apps have no
+// URL and are skipped.
+func toLinks(attachments []slack.Attachment) []provider.Attachment {
+	var rtn []provider.Attachment
+	for _, a := range attachments {
+		url := a.FromURL
+		if url == "" {
+			url = a.OriginalURL
+		}
+		if url == "" {
+			continue
+		}
+		name := a.Title
+		if name == "" {
+			name = a.ServiceName
+		}
+		rtn = append(rtn, provider.Attachment{
+			Type:        provider.AttachmentTypeLink,
+			Name:        name,
+			URL:         url,
+			Description: a.Text,
+		})
+	}
+	return rtn
+}
@@ -0,0 +1,96 @@
+package slack
+
+import (
+	"context"
+	"database/sql"
+
+	"github.com/cockroachdb/errors"
+	"github.com/slack-go/slack"
+
+	botsvc "encore.app/bot"
+	botdb "encore.app/bot/db"
+	"encore.app/chat/provider/slack/db"
+	"encore.dev/storage/sqldb"
+	"encore.dev/types/uuid"
+)
+
+// This uses Encore's declarative database , learn more: https://encore.dev/docs/primitives/databases
+var slackdb = sqldb.NewDatabase("slack", sqldb.DatabaseConfig{
+	Migrations: "./db/migrations",
+})
+
+type RegisterBotAppRequest struct {
+	// Token is the Bot User OAuth Token of the bot's Slack app
+	Token string
+}
+
+type RegisterBotAppResponse struct {
+	// UserID is the Slack user ID of the bot, it's used to mention the bot natively
+	UserID string
+}
+
+// RegisterBotApp gives a bot its own Slack identity. The bot posts, reacts and joins and leaves channels with the
+// token of its own Slack app instead of the shared app, so it's a real member of the channels it's in.
+// Bots without an app fall back to the shared app.
+//
+//encore:api private method=POST path=/slack/bots/:botID/app
+func (s *Service) RegisterBotApp(ctx context.Context, botID uuid.UUID, req *RegisterBotAppRequest) (*RegisterBotAppResponse, error) {
+	if _, err := botsvc.Get(ctx, botID); err != nil {
+		return nil, errors.Wrap(err, "get bot")
+	}
+	resp, err := slack.New(req.Token).AuthTestContext(ctx)
+	if err != nil {
+		return nil, errors.Wrap(err, "auth test")
+	}
+	if resp.BotID == s.botID {
+		return nil, errors.New("the token belongs to the shared app")
+	}
+	app, err := db.New().UpsertBotApp(ctx, slackdb.Stdlib(), db.UpsertBotAppParams{
+		BotID:      botID,
+		Token:      req.Token,
+		UserID:     resp.UserID,
+		SlackBotID: resp.BotID,
+	})
+	if err != nil {
+		return nil, errors.Wrap(err, "upsert bot app")
+	}
+	return &RegisterBotAppResponse{UserID: app.UserID}, nil
+}
+
+// RemoveBotApp removes the Slack app of a bot, the bot falls back to the shared app. The bot's app stays in the
+// channels it has joined until it's removed from the workspace.
+//
+//encore:api private method=DELETE path=/slack/bots/:botID/app
+func (s *Service) RemoveBotApp(ctx context.Context, botID uuid.UUID) error {
+	err := db.New().DeleteBotApp(ctx, slackdb.Stdlib(), botID)
+	return errors.Wrap(err, "delete bot app")
+}
+
+// botClient returns a client for the bot's own Slack app. It returns nil if the bot doesn't have an app.
+func (s *Service) botClient(ctx context.Context, bot *botdb.Bot) (*slack.Client, error) {
+	app, err := db.New().GetBotApp(ctx, slackdb.Stdlib(), bot.ID)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, nil
+	} else if err != nil {
+		return nil, errors.Wrap(err, "get bot app")
+	}
+	return slack.New(app.Token), nil
+}
+
+// botAppBySlackBotID returns the bot app with the given Slack bot ID, or nil if it isn't the app of a bot.
+func botAppBySlackBotID(ctx context.Context, slackBotID string) (*db.BotApp, error) {
+	app, err := db.New().GetBotAppBySlackBotID(ctx, slackdb.Stdlib(), slackBotID)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, nil
+	}
+	return app, errors.Wrap(err, "get bot app")
+}
+
+// botAppByUserID returns the bot app with the given Slack user ID, or nil if the user isn't the user of a bot app.
+func botAppByUserID(ctx context.Context, userID string) (*db.BotApp, error) {
+	app, err := db.New().GetBotAppByUserID(ctx, slackdb.Stdlib(), userID)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, nil
+	}
+	return app, errors.Wrap(err, "get bot app")
+}
@@ -0,0 +1,67 @@
+package slack
+
+import (
+	"context"
+	"encoding/json"
+
+	"github.com/cockroachdb/errors"
+	"github.com/slack-go/slack"
+
+	"encore.app/chat/provider"
+	chatdb "encore.app/chat/service/db"
+)
+
+// toChannelEvent converts the events of renamed, archived, unarchived and deleted channels to a channel event. It
+// returns nil for all other events. The group_ events are the same for private channels.
+func (svc *Service) toChannelEvent(ctx context.Context, event json.RawMessage) (*provider.ChannelEvent, error) {
+	var ev struct {
+		Type string `json:"type"`
+		// Channel is the renamed channel object for rename events, and the channel ID for all others
+		Channel json.RawMessage `json:"channel"`
+	}
+	if err := json.Unmarshal(event, &ev); err != nil {
+		return nil, errors.Wrap(err, "unmarshal event")
+	}
+	var typ string
+	switch ev.Type {
+	case "channel_rename", "group_rename":
+		var channel struct {
+			ID   string `json:"id"`
+			Name string `json:"name"`
+		}
+		if err := json.Unmarshal(ev.Channel, &channel); err != nil {
+			return nil, errors.Wrap(err, "unmarshal channel")
+		}
+		return &provider.ChannelEvent{
+			Provider: chatdb.ProviderSlack,
+			Type:     provider.ChannelEventUpserted,
+			Channels: []provider.ChannelInfo{{Provider: chatdb.ProviderSlack, ID: channel.ID, Name: channel.Name}},
+		}, nil
+	case "channel_unarchive", "group_unarchive":
+		typ = provider.ChannelEventRestored
+	case "channel_archive", "group_archive":
+		typ = provider.ChannelEventArchived
+	case "channel_deleted", "group_deleted":
+		typ = provider.ChannelEventDeleted
+	default:
+		return nil, nil
+	}
+	var channelID string
+	if err := json.Unmarshal(ev.Channel, &channelID); err != nil {
+		return nil, errors.Wrap(err, "unmarshal channel ID")
+	}
+	info := provider.ChannelInfo{Provider: chatdb.ProviderSlack, ID: channelID}
+	if typ == provider.ChannelEventRestored {
+		// Unarchive events only have the channel ID, but upserting needs the name
+		channel, err := svc.client.GetConversationInfoContext(ctx, &slack.GetConversationInfoInput{ChannelID: channelID})
+		if err != nil {
+			return nil, errors.Wrap(err, "get channel info")
+		}
+		info = svc.toChannelInfo(ctx, channel)
+	}
+	return &provider.ChannelEvent{
+		Provider: chatdb.ProviderSlack,
+		Type:     typ,
+		Channels: []provider.ChannelInfo{info},
+	}, nil
+}
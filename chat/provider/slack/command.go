@@ -0,0 +1,305 @@
+package slack
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/url"
+	"slices"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/cockroachdb/errors"
+	"github.com/slack-go/slack"
+
+	botsvc "encore.app/bot"
+	botdb "encore.app/bot/db"
+	"encore.app/chat/provider"
+	chatdb "encore.app/chat/service/db"
+	"encore.dev/rlog"
+)
+
+const (
+	// botPickerCallbackID identifies submissions of the bot picker modal
+	botPickerCallbackID = "aichat_bot_picker"
+	// botPickerBlockID is the block and action ID of the bot select in the bot picker modal
+	botPickerBlockID = "bots"
+	// maxPickerProfiles is the maximum number of bots listed with their profile in the bot picker. Modals are
+	// limited to 100 blocks.
+	maxPickerProfiles = 50
+	// maxPickerOptions is the maximum number of options in a select menu
+	maxPickerOptions = 100
+)
+
+// noPermission is the response to users who aren't allowed to manage bots
+const noPermission = "You don't have permission to manage bots in this workspace."
+
+const commandUsage = "Usage:\n" +
+	"• `/aichat` or `/aichat add` opens a picker to add bots to this channel\n" +
+	"• `/aichat add <name>` adds a bot to this channel\n" +
+	"• `/aichat remove <name>` removes a bot from this channel\n" +
+	"• `/aichat list` lists the bots in this channel\n" +
+	"• `/aichat instruct <name> <instruction>` instructs a bot, e.g. to start a new topic\n" +
+	"• `/aichat create <name> <prompt>` creates a new bot\n" +
+	"Names with spaces must be quoted, e.g. `/aichat add \"Ann Lee\"`."
+
+// botPickerMetadata is stored in the private metadata of the bot picker modal
+type botPickerMetadata struct {
+	ChannelID   string `json:"channel_id"`
+	ResponseURL string `json:"response_url"`
+}
+
+// SlackCommandHandler handles the /aichat slash command. Commands are acknowledged right away and published to the
+// command topic, the chat service responds to them with ephemeral messages when it's done.
+//
+//encore:api public raw method=POST path=/slack/command
+func (svc *Service) SlackCommandHandler(w http.ResponseWriter, r *http.Request) {
+	body, ok := svc.readVerifiedBody(w, r)
+	if !ok {
+		return
+	}
+	r.Body = io.NopCloser(bytes.NewReader(body))
+	cmd, err := slack.SlashCommandParse(r)
+	if err != nil {
+		http.Error(w, "invalid command", http.StatusBadRequest)
+		return
+	}
+	resp := svc.handleSlashCommand(r.Context(), &cmd)
+	if resp == nil {
+		w.WriteHeader(http.StatusOK)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	_ = json.NewEncoder(w).Encode(resp)
+}
+
+// SlackInteractionHandler handles interactivity payloads, i.e. submissions of the bot picker modal.
+//
+//encore:api public raw method=POST path=/slack/interactivity
+func (svc *Service) SlackInteractionHandler(w http.ResponseWriter, r *http.Request) {
+	body, ok := svc.readVerifiedBody(w, r)
+	if !ok {
+		return
+	}
+	form, err := url.ParseQuery(string(body))
+	if err != nil {
+		http.Error(w, "invalid payload", http.StatusBadRequest)
+		return
+	}
+	var callback slack.InteractionCallback
+	if err := json.Unmarshal([]byte(form.Get("payload")), &callback); err != nil {
+		http.Error(w, "invalid payload", http.StatusBadRequest)
+		return
+	}
+	if err := svc.handleInteraction(r.Context(), &callback); err != nil {
+		rlog.Error("handle slack interaction", "error", err)
+		http.Error(w, "handle interaction", http.StatusInternalServerError)
+		return
+	}
+	w.WriteHeader(http.StatusOK)
+}
+
+// handleSlashCommand handles an /aichat command and returns the immediate response to the user, if any.
+// It's shared by the webhook and socket mode transports.
+func (svc *Service) handleSlashCommand(ctx context.Context, cmd *slack.SlashCommand) *slack.Msg {
+	sub, name, text := parseCommand(cmd.Text)
+	if sub != provider.CommandListBots && !svc.canManageBots(ctx, cmd.UserID) {
+		return ephemeral(noPermission)
+	}
+	if sub == "" || (sub == provider.CommandAddBot && name == "") {
+		err := svc.openBotPicker(ctx, cmd)
+		if err != nil {
+			rlog.Error("open bot picker", "error", err)
+			return ephemeral("The bot picker couldn't be opened, please try again.")
+		}
+		return nil
+	}
+	switch sub {
+	case provider.CommandListBots:
+	case provider.CommandAddBot, provider.CommandRemoveBot:
+		if name == "" {
+			return ephemeral(commandUsage)
+		}
+	case provider.CommandInstruct, provider.CommandCreateBot:
+		if name == "" || text == "" {
+			return ephemeral(commandUsage)
+		}
+	default:
+		return ephemeral(commandUsage)
+	}
+	err := svc.publishCommand(ctx, &provider.Command{
+		Provider:  chatdb.ProviderSlack,
+		ChannelID: cmd.ChannelID,
+		User:      provider.User{ID: cmd.UserID, Name: cmd.UserName},
+		Name:      sub,
+		Bot:       name,
+		Text:      text,
+		Token:     cmd.ResponseURL,
+	})
+	if err != nil {
+		rlog.Error("publish command", "error", err)
+		return ephemeral("Something went wrong, please try again.")
+	}
+	return nil
+}
+
+// handleInteraction handles an interactivity payload. Bots selected in the bot picker are added to the channel the
+// picker was opened in. It's shared by the webhook and socket mode transports.
+func (svc *Service) handleInteraction(ctx context.Context, callback *slack.InteractionCallback) error {
+	if callback.Type != slack.InteractionTypeViewSubmission || callback.View.CallbackID != botPickerCallbackID {
+		return nil
+	}
+	var meta botPickerMetadata
+	if err := json.Unmarshal([]byte(callback.View.PrivateMetadata), &meta); err != nil {
+		return errors.Wrap(err, "unmarshal metadata")
+	}
+	if callback.View.State == nil {
+		return nil
+	}
+	if !svc.canManageBots(ctx, callback.User.ID) {
+		return svc.postEphemeral(ctx, meta.ResponseURL, noPermission)
+	}
+	selected := callback.View.State.Values[botPickerBlockID][botPickerBlockID].SelectedOptions
+	for _, opt := range selected {
+		err := svc.publishCommand(ctx, &provider.Command{
+			Provider:  chatdb.ProviderSlack,
+			ChannelID: meta.ChannelID,
+			User:      provider.User{ID: callback.User.ID, Name: callback.User.Name},
+			Name:      provider.CommandAddBot,
+			Bot:       opt.Value,
+			Token:     meta.ResponseURL,
+		})
+		if err != nil {
+			return errors.Wrap(err, "publish command")
+		}
+	}
+	return nil
+}
+
+// canManageBots returns true if the user is allowed to add, remove, instruct and create bots. Users need to be
+// workspace admins or owners, or one of the configured manager users.
+func (svc *Service) canManageBots(ctx context.Context, userID string) bool {
+	if slices.Contains(cfg.ManagerUsers(), userID) {
+		return true
+	}
+	user, err := svc.client.GetUserInfoContext(ctx, userID)
+	if err != nil {
+		rlog.Warn("get user info for permission check", "user", userID, "error", err)
+		return false
+	}
+	return user.IsAdmin || user.IsOwner || user.IsPrimaryOwner
+}
+
+func (svc *Service) publishCommand(ctx context.Context, cmd *provider.Command) error {
+	_, err := provider.CommandTopic.Publish(ctx, cmd)
+	return errors.Wrap(err, "publish command")
+}
+
+// openBotPicker opens a modal to pick bots to add to the channel. Slack rejects views with images it can't
+// download, so the picker is opened without avatars if that fails, e.g. when the app isn't publicly reachable.
+func (svc *Service) openBotPicker(ctx context.Context, cmd *slack.SlashCommand) error {
+	resp, err := botsvc.List(ctx, &botsvc.ListBotRequest{})
+	if err != nil {
+		return errors.Wrap(err, "list bots")
+	}
+	if len(resp.Bots) == 0 {
+		return svc.postEphemeral(ctx, cmd.ResponseURL, "There are no bots yet, create one with `/aichat create <name> <prompt>`.")
+	}
+	meta, err := json.Marshal(&botPickerMetadata{
+		ChannelID:   cmd.ChannelID,
+		ResponseURL: cmd.ResponseURL,
+	})
+	if err != nil {
+		return errors.Wrap(err, "marshal metadata")
+	}
+	_, err = svc.client.OpenViewContext(ctx, cmd.TriggerID, botPickerView(resp.Bots, string(meta), true))
+	if err != nil {
+		rlog.Warn("open bot picker with avatars", "error", err)
+		_, err = svc.client.OpenViewContext(ctx, cmd.TriggerID, botPickerView(resp.Bots, string(meta), false))
+	}
+	return errors.Wrap(err, "open view")
+}
+
+// botPickerView creates the bot picker modal. Each bot is listed with its profile and optionally its avatar,
+// followed by a select menu to pick the bots to add.
+func botPickerView(bots []*botdb.Bot, metadata string, withAvatars bool) slack.ModalViewRequest {
+	var blocks []slack.Block
+	var options []*slack.OptionBlockObject
+	for i, b := range bots {
+		if i < maxPickerProfiles {
+			var accessory *slack.Accessory
+			if withAvatars {
+				accessory = slack.NewAccessory(slack.NewImageBlockElement(b.GetAvatarURL(), b.Name))
+			}
+			text := slack.NewTextBlockObject(slack.MarkdownType, "*"+b.Name+"*\n"+truncate(b.Profile, 200), false, false)
+			blocks = append(blocks, slack.NewSectionBlock(text, nil, accessory))
+		}
+		if i < maxPickerOptions {
+			options = append(options, slack.NewOptionBlockObject(b.Name, slack.NewTextBlockObject(slack.PlainTextType, truncate(b.Name, 75), false, false), nil))
+		}
+	}
+	selectMenu := slack.NewOptionsMultiSelectBlockElement(slack.MultiOptTypeStatic,
+		slack.NewTextBlockObject(slack.PlainTextType, "Select bots", false, false), botPickerBlockID, options...)
+	blocks = append(blocks, slack.NewInputBlock(botPickerBlockID,
+		slack.NewTextBlockObject(slack.PlainTextType, "Bots to add to the channel", false, false), nil, selectMenu))
+	return slack.ModalViewRequest{
+		Type:            slack.VTModal,
+		Title:           slack.NewTextBlockObject(slack.PlainTextType, "Add bots", false, false),
+		Submit:          slack.NewTextBlockObject(slack.PlainTextType, "Add", false, false),
+		Close:           slack.NewTextBlockObject(slack.PlainTextType, "Cancel", false, false),
+		Blocks:          slack.Blocks{BlockSet: blocks},
+		CallbackID:      botPickerCallbackID,
+		PrivateMetadata: metadata,
+	}
+}
+
+// RespondToCommand responds to an /aichat command with an ephemeral message.
+//
+//encore:api private method=POST path=/slack/commands/respond
+func (svc *Service) RespondToCommand(ctx context.Context, req *provider.CommandResponse) error {
+	return svc.postEphemeral(ctx, req.Token, req.Content)
+}
+
+// postEphemeral posts a message to the response URL of a command, it's only visible to the user who issued it.
+func (svc *Service) postEphemeral(ctx context.Context, responseURL, text string) error {
+	err := slack.PostWebhookContext(ctx, responseURL, &slack.WebhookMessage{
+		Text:         text,
+		ResponseType: slack.ResponseTypeEphemeral,
+	})
+	return errors.Wrap(err, "post response")
+}
+
+// parseCommand splits the text of an /aichat command into the subcommand, the bot name and the remaining text.
+// Bot names with spaces can be quoted, e.g. `instruct "Ann Lee" say hi`.
+func parseCommand(text string) (sub, name, rest string) {
+	sub, args, _ := strings.Cut(strings.TrimSpace(text), " ")
+	sub = strings.ToLower(sub)
+	args = strings.TrimSpace(args)
+	// Slack clients may replace quotes with typographic ones
+	if open, size := utf8.DecodeRuneInString(args); open == '"' || open == '“' {
+		end := strings.IndexFunc(args[size:], func(r rune) bool { return r == '"' || r == '”' })
+		if end >= 0 {
+			_, closeSize := utf8.DecodeRuneInString(args[size+end:])
+			return sub, args[size : size+end], strings.TrimSpace(args[size+end+closeSize:])
+		}
+	}
+	name, rest, _ = strings.Cut(args, " ")
+	return sub, name, strings.TrimSpace(rest)
+}
+
+func ephemeral(text string) *slack.Msg {
+	return &slack.Msg{
+		ResponseType: slack.ResponseTypeEphemeral,
+		Text:         text,
+	}
+}
+
+// truncate shortens s to at most n runes
+func truncate(s string, n int) string {
+	if runes := []rune(s); len(runes) > n {
+		return string(runes[:n-1]) + "…"
+	}
+	return s
+}
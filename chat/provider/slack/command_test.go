@@ -0,0 +1,24 @@
+package slack
+
+import "testing"
+
+func TestParseCommand(t *testing.T) {
+	tests := []struct {
+		text                        string
+		wantSub, wantName, wantRest string
+	}{
+		{text: "", wantSub: "", wantName: "", wantRest: ""},
+		{text: "list", wantSub: "list", wantName: "", wantRest: ""},
+		{text: " ADD  Ann ", wantSub: "add", wantName: "Ann", wantRest: ""},
+		{text: "instruct Ann talk about the weather", wantSub: "instruct", wantName: "Ann", wantRest: "talk about the weather"},
+		{text: `instruct "Ann Lee" say hi`, wantSub: "instruct", wantName: "Ann Lee", wantRest: "say hi"},
+		{text: "create “Ann Lee” a grumpy pirate", wantSub: "create", wantName: "Ann Lee", wantRest: "a grumpy pirate"},
+		{text: `remove "Ann Lee`, wantSub: "remove", wantName: `"Ann`, wantRest: "Lee"},
+	}
+	for _, tt := range tests {
+		sub, name, rest := parseCommand(tt.text)
+		if sub != tt.wantSub || name != tt.wantName || rest != tt.wantRest {
+			t.Errorf("parseCommand(%q) = %q, %q, %q, want %q, %q, %q", tt.text, sub, name, rest, tt.wantSub, tt.wantName, tt.wantRest)
+		}
+	}
+}
@@ -0,0 +1,11 @@
+// Transport is either "webhook" or "socket". The socket transport uses Slack's Socket Mode and
+// doesn't need a public URL, but requires the SlackAppToken secret.
+Transport: *"webhook" | "socket"
+
+// ExtractAttachmentText downloads plain text and markdown files shared in Slack so the bots can read
+// them. It requires the files:read scope.
+ExtractAttachmentText: bool | *true
+
+// ManagerUsers are the IDs of the users which are allowed to manage bots with the /aichat command, e.g.
+// "U024BE7LH". Workspace admins and owners can always manage bots.
+ManagerUsers: [...string] | *[]
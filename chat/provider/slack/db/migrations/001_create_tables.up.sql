@@ -0,0 +1,7 @@
+CREATE TABLE bot_app
+(
+    bot_id       uuid PRIMARY KEY,
+    token        TEXT NOT NULL,
+    user_id      TEXT NOT NULL,
+    slack_bot_id TEXT NOT NULL
+)
@@ -0,0 +1,16 @@
+-- name: UpsertBotApp :one
+INSERT INTO bot_app (bot_id, token, user_id, slack_bot_id) VALUES ($1, $2, $3, $4)
+ON CONFLICT (bot_id) DO UPDATE SET token = $2, user_id = $3, slack_bot_id = $4
+RETURNING *;
+
+-- name: GetBotApp :one
+SELECT * FROM bot_app WHERE bot_id = $1;
+
+-- name: GetBotAppBySlackBotID :one
+SELECT * FROM bot_app WHERE slack_bot_id = $1;
+
+-- name: GetBotAppByUserID :one
+SELECT * FROM bot_app WHERE user_id = $1;
+
+-- name: DeleteBotApp :exec
+DELETE FROM bot_app WHERE bot_id = $1;
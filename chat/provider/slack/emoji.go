@@ -0,0 +1,100 @@
+package slack
+
+import (
+	"strings"
+)
+
+// emojiNames maps unicode emojis to their Slack names. Slack only accepts names when adding reactions, while the
+// other providers and the LLMs use unicode. It covers the emojis commonly used as reactions.
+var emojiNames = map[string]string{
+	"👍": "+1",
+	"👎": "-1",
+	"❤": "heart",
+	"😂": "joy",
+	"🤣": "rolling_on_the_floor_laughing",
+	"😄": "smile",
+	"😃": "smiley",
+	"😀": "grinning",
+	"😊": "blush",
+	"😉": "wink",
+	"😍": "heart_eyes",
+	"🥰": "smiling_face_with_3_hearts",
+	"😎": "sunglasses",
+	"🤔": "thinking_face",
+	"🙄": "face_with_rolling_eyes",
+	"😮": "open_mouth",
+	"😱": "scream",
+	"😢": "cry",
+	"😭": "sob",
+	"😡": "rage",
+	"😅": "sweat_smile",
+	"😬": "grimacing",
+	"🤯": "exploding_head",
+	"🥳": "partying_face",
+	"🤷": "shrug",
+	"🙏": "pray",
+	"👏": "clap",
+	"🙌": "raised_hands",
+	"👀": "eyes",
+	"💯": "100",
+	"🔥": "fire",
+	"🎉": "tada",
+	"✨": "sparkles",
+	"⭐": "star",
+	"🚀": "rocket",
+	"💡": "bulb",
+	"✅": "white_check_mark",
+	"❌": "x",
+	"⚠": "warning",
+	"👌": "ok_hand",
+	"💪": "muscle",
+	"🤝": "handshake",
+	"👋": "wave",
+	"🍕": "pizza",
+	"☕": "coffee",
+	"🍻": "beers",
+	"🐔": "chicken",
+	"💀": "skull",
+	"🤦": "face_palm",
+	"🫡": "saluting_face",
+}
+
+// emojiByName is the reverse of emojiNames, including aliases Slack uses in events.
+var emojiByName = func() map[string]string {
+	rtn := map[string]string{
+		"thumbsup":   "👍",
+		"thumbsdown": "👎",
+		"facepalm":   "🤦",
+	}
+	for emoji, name := range emojiNames {
+		rtn[name] = emoji
+	}
+	return rtn
+}()
+
+// toSlackEmoji returns the Slack name of an emoji. Emojis can either be given as unicode or as :name:.
+func toSlackEmoji(emoji string) (string, bool) {
+	if name, ok := strings.CutPrefix(emoji, ":"); ok {
+		return strings.TrimSuffix(name, ":"), true
+	}
+	// Drop variation selectors and skin tones, which aren't part of the names
+	emoji = strings.Map(func(r rune) rune {
+		if r == '\ufe0f' || (r >= 0x1f3fb && r <= 0x1f3ff) {
+			return -1
+		}
+		return r
+	}, emoji)
+	name, ok := emojiNames[emoji]
+	return name, ok
+}
+
+// fromSlackEmoji converts a Slack emoji name to unicode. Emojis without a known unicode representation,
+// e.g. custom emojis, are returned as :name:.
+func fromSlackEmoji(name string) string {
+	// Skin tones are appended to the name, e.g. +1::skin-tone-2
+	name, _, _ = strings.Cut(name, "::")
+	if emoji, ok := emojiByName[name]; ok {
+		return emoji
+	}
+	return ":" + name + ":"
+}
@@ -6,6 +6,8 @@ import (
 	"context"
 	"encoding/json"
 	"fmt"
+	"io"
+	"net/http"
 	"slices"
 	"strconv"
 	"strings"
@@ -13,10 +15,13 @@ import (
 
 	"github.com/cockroachdb/errors"
 	"github.com/slack-go/slack"
+	"github.com/slack-go/slack/slackevents"
 
+	botsvc "encore.app/bot"
 	botdb "encore.app/bot/db"
 	"encore.app/chat/provider"
 	chatdb "encore.app/chat/service/db"
+	"encore.dev/config"
 	"encore.dev/rlog"
 	"encore.dev/types/uuid"
 )
@@ -26,17 +31,50 @@ const (
 	BotMessageEventType = "bot_message"
 )
 
+const (
+	TransportWebhook = "webhook"
+	TransportSocket  = "socket"
+)
+
+const (
+	// maxHistoryPageSize is the maximum number of messages fetched per conversations.history request
+	maxHistoryPageSize = 200
+	// maxRateLimitRetries is how many times a rate limited request is retried
+	maxRateLimitRetries = 3
+)
+
 // This uses Encore's built-in secrets manager, learn more: https://encore.dev/docs/primitives/secrets
 var secrets struct {
 	SlackToken string
+	// SlackSigningSecret is used to verify that webhook requests were sent by Slack.
+	SlackSigningSecret string
+	// SlackAppToken is an app-level token with the connections:write scope. It's only required when
+	// using the socket mode transport.
+	SlackAppToken string
+}
+
+type Config struct {
+	// Transport decides how events are received from Slack. Either "webhook" (the default) which
+	// requires a public URL, or "socket" which uses Slack's Socket Mode.
+	Transport config.String
+	// ExtractAttachmentText decides if the text of plain text and markdown files shared in Slack is
+	// downloaded and included in the chat history.
+	ExtractAttachmentText config.Bool
+	// ManagerUsers are the IDs of the users which are allowed to manage bots with the /aichat command
+	ManagerUsers config.Values[string]
 }
 
+// This uses Encore Configuration, learn more: https://encore.dev/docs/develop/config
+var cfg = config.Load[*Config]()
+
 // This declares a Encore Service, learn more: https://encore.dev/docs/primitives/services-and-apis/service-structs
 //
 //encore:service
 type Service struct {
-	client *slack.Client
-	botID  string
+	client     *slack.Client
+	botID      string
+	userID     string
+	deliveries *deliveryTracker
 }
 
 // initService initializes the Slack service by creating a client and retrieving the bot ID.
@@ -45,15 +83,28 @@ func initService() (*Service, error) {
 	if secrets.SlackToken == "" {
 		return nil, nil
 	}
-	client := slack.New(secrets.SlackToken)
+	client := slack.New(secrets.SlackToken, slack.OptionAppLevelToken(secrets.SlackAppToken))
 	resp, err := client.AuthTest()
 	if err != nil {
 		return nil, errors.Wrap(err, "auth test")
 	}
-	return &Service{
-		client: client,
-		botID:  resp.BotID,
-	}, nil
+	svc := &Service{
+		client:     client,
+		botID:      resp.BotID,
+		userID:     resp.UserID,
+		deliveries: newDeliveryTracker(),
+	}
+	switch cfg.Transport() {
+	case TransportWebhook:
+	case TransportSocket:
+		if secrets.SlackAppToken == "" {
+			return nil, errors.New("the socket transport requires the SlackAppToken secret")
+		}
+		go svc.runSocketMode(context.Background())
+	default:
+		return nil, errors.Newf("unknown slack transport %q", cfg.Transport())
+	}
+	return svc, nil
 }
 
 // Ping returns an error if the service is not available.
@@ -69,6 +120,7 @@ type SlackEvent struct {
 	Token     string          `json:"token"`
 	Challenge string          `json:"challenge"`
 	Type      string          `json:"type"`
+	EventID   string          `json:"event_id"`
 	Event     json.RawMessage `json:"event"`
 }
 
@@ -76,35 +128,117 @@ type ChallengeResponse struct {
 	Challenge string `json:"challenge"`
 }
 
+// maxEventSize is the maximum size of an event payload accepted by the webhook.
+const maxEventSize = 1 << 20
+
 // SlackEventHandler handles incoming slack messages and publishes them to the message topic.
 // The webhook URL must be set in the slack app configuration.
+// Every request is verified using the app's signing secret, and retries of already delivered events are
+// acknowledged without being published again.
 // To test it locally, you can use the ngrok integration in the proxy package which automatically spins up
-// a tunnel to your local machine. Learn more: https://ngrok.com/
+// a tunnel to your local machine, or switch to the socket transport. Learn more: https://ngrok.com/
 //
-//encore:api public path=/slack/message
-func (svc *Service) SlackEventHandler(ctx context.Context, req *SlackEvent) (*ChallengeResponse, error) {
+//encore:api public raw method=POST path=/slack/message
+func (svc *Service) SlackEventHandler(w http.ResponseWriter, r *http.Request) {
+	body, ok := svc.readVerifiedBody(w, r)
+	if !ok {
+		return
+	}
+	var req SlackEvent
+	if err := json.Unmarshal(body, &req); err != nil {
+		http.Error(w, "invalid event", http.StatusBadRequest)
+		return
+	}
 	switch req.Type {
 	case "url_verification":
-		return &ChallengeResponse{
-			Challenge: req.Challenge,
-		}, nil
+		w.Header().Set("Content-Type", "application/json")
+		_ = json.NewEncoder(w).Encode(&ChallengeResponse{Challenge: req.Challenge})
+		return
 	case "event_callback":
-		discordMsg := Message{}
-		err := json.Unmarshal(req.Event, &discordMsg)
-		if err != nil {
-			return nil, errors.Wrap(err, "unmarshal message")
+		if req.EventID != "" && !svc.deliveries.claim(req.EventID, time.Now()) {
+			rlog.Debug("skipping duplicate slack event", "event_id", req.EventID, "retry", r.Header.Get("X-Slack-Retry-Num"))
+			w.WriteHeader(http.StatusOK)
+			return
 		}
-		msg := svc.toProviderMessage(discordMsg.Msg, discordMsg.Channel)
-		// Some messages we just want to ignore
-		if msg == nil {
-			return nil, nil
+		if err := svc.handleEventCallback(r.Context(), &req); err != nil {
+			svc.deliveries.release(req.EventID)
+			rlog.Error("handle slack event", "error", err)
+			http.Error(w, "handle event", http.StatusInternalServerError)
+			return
 		}
-		_, err = provider.InboxTopic.Publish(ctx, msg)
-		if err != nil {
-			return nil, errors.Wrap(err, "publish message")
+	}
+	w.WriteHeader(http.StatusOK)
+}
+
+// readVerifiedBody reads the request body and verifies the Slack signature. It writes an error response
+// and returns false if the request can't be verified.
+func (svc *Service) readVerifiedBody(w http.ResponseWriter, r *http.Request) ([]byte, bool) {
+	if svc == nil {
+		http.Error(w, "slack is not configured", http.StatusServiceUnavailable)
+		return nil, false
+	}
+	if secrets.SlackSigningSecret == "" {
+		rlog.Error("rejecting slack request, the SlackSigningSecret secret is not set")
+		http.Error(w, "slack signing secret is not configured", http.StatusUnauthorized)
+		return nil, false
+	}
+	body, err := io.ReadAll(http.MaxBytesReader(w, r.Body, maxEventSize))
+	var tooLarge *http.MaxBytesError
+	if errors.As(err, &tooLarge) {
+		rlog.Warn("rejecting slack request, the body is too large", "limit", maxEventSize)
+		http.Error(w, "request body too large", http.StatusRequestEntityTooLarge)
+		return nil, false
+	} else if err != nil {
+		http.Error(w, "read body", http.StatusBadRequest)
+		return nil, false
+	}
+	if err := verifyRequest(r.Header, body, secrets.SlackSigningSecret, time.Now()); err != nil {
+		rlog.Warn("rejecting slack request", "error", err)
+		http.Error(w, "invalid signature", http.StatusUnauthorized)
+		return nil, false
+	}
+	return body, true
+}
+
+// handleEventCallback converts an event_callback payload to a provider message and publishes it to the
+// message topic, or to the channel topic for changes to channels. It's shared by the webhook and socket mode
+// transports.
+func (svc *Service) handleEventCallback(ctx context.Context, req *SlackEvent) error {
+	// Channel events have a different shape than messages, so they're handled first
+	channelEvent, err := svc.toChannelEvent(ctx, req.Event)
+	if err != nil {
+		return errors.Wrap(err, "convert channel event")
+	} else if channelEvent != nil {
+		_, err = provider.ChannelTopic.Publish(ctx, channelEvent)
+		return errors.Wrap(err, "publish channel event")
+	}
+	slackMsg := Message{}
+	err = json.Unmarshal(req.Event, &slackMsg)
+	if err != nil {
+		return errors.Wrap(err, "unmarshal message")
+	}
+	var msg *provider.Message
+	switch {
+	case slackMsg.Type == "reaction_added" || slackMsg.Type == "reaction_removed":
+		var reaction slackevents.ReactionAddedEvent
+		if err := json.Unmarshal(req.Event, &reaction); err != nil {
+			return errors.Wrap(err, "unmarshal reaction")
 		}
+		msg, err = svc.toReactionMessage(ctx, &reaction)
+	case slackMsg.SubType == "message_changed" || slackMsg.SubType == "message_deleted":
+		msg = toChangedMessage(&slackMsg, slackMsg.Channel)
+	default:
+		msg, err = svc.toProviderMessage(ctx, slackMsg.Msg, slackMsg.Channel)
+	}
+	if err != nil {
+		return errors.Wrap(err, "convert message")
 	}
-	return nil, nil
+	// Some messages we just want to ignore
+	if msg == nil {
+		return nil
+	}
+	_, err = provider.InboxTopic.Publish(ctx, msg)
+	return errors.Wrap(err, "publish message")
 }
 
 // ListChannels returns a list of channels in the slack workspace.
@@ -114,21 +248,44 @@ func (s *Service) ListChannels(ctx context.Context) (*provider.ListChannelsRespo
 	resp, _, err := s.client.GetConversationsContext(ctx, &slack.GetConversationsParameters{
 		Types: []string{"public_channel", "private_channel", "mpim", "im"},
 		Limit: 1000,
+		// Archived channels would be listed too, they're only restored when they're unarchived
+		ExcludeArchived: true,
 	})
 	if err != nil {
 		return nil, err
 	}
 	var rtn []provider.ChannelInfo
 	for _, channel := range resp {
-		rtn = append(rtn, provider.ChannelInfo{
-			Provider: chatdb.ProviderSlack,
-			ID:       channel.ID,
-			Name:     channel.Name,
-		})
+		rtn = append(rtn, s.toChannelInfo(ctx, &channel))
 	}
 	return &provider.ListChannelsResponse{Channels: rtn}, nil
 }
 
+// toChannelInfo converts a slack conversation to a ChannelInfo. Direct message conversations don't have a name,
+// so they are named after the user.
+func (s *Service) toChannelInfo(ctx context.Context, channel *slack.Channel) provider.ChannelInfo {
+	info := provider.ChannelInfo{
+		Provider: chatdb.ProviderSlack,
+		ID:       channel.ID,
+		Name:     channel.Name,
+	}
+	if channel.IsIM {
+		info.DMUser = channel.User
+		info.Name = "dm-" + channel.User
+		if user, err := s.GetUser(ctx, channel.User); err != nil {
+			rlog.Warn("get dm user", "user", channel.User, "error", err)
+		} else if user != nil {
+			info.Name = "dm-" + user.Name
+		}
+	}
+	return info
+}
+
+// isDM returns true if the channel ID is a direct message conversation
+func isDM(channelID string) bool {
+	return strings.HasPrefix(channelID, "D")
+}
+
 // GetUser returns a user by ID.
 //
 //encore:api private method=GET path=/slack/users/:userID
@@ -136,6 +293,16 @@ func (s *Service) GetUser(ctx context.Context, userID string) (*provider.User, e
 	if strings.HasPrefix(userID, "B") {
 		return nil, nil
 	}
+	// Bots with their own Slack app are known by their bot name
+	if app, err := botAppByUserID(ctx, userID); err != nil {
+		return nil, errors.Wrap(err, "get bot app")
+	} else if app != nil {
+		bot, err := botsvc.Get(ctx, app.BotID)
+		if err != nil {
+			return nil, errors.Wrap(err, "get bot")
+		}
+		return &provider.User{ID: userID, Name: bot.Name, BotID: bot.ID}, nil
+	}
 	user, err := s.client.GetUserInfo(userID)
 	if err != nil {
 		return nil, errors.Wrapf(err, "get user info: %s", userID)
@@ -154,25 +321,54 @@ func (s *Service) GetUser(ctx context.Context, userID string) (*provider.User, e
 type Message struct {
 	slack.Msg
 	Blocks json.RawMessage `json:"blocks"`
+	// SubMessage and PreviousMessage are set for message_changed events
+	SubMessage      *Message `json:"message,omitempty"`
+	PreviousMessage *Message `json:"previous_message,omitempty"`
 }
 
-// LeaveChannel leaves a slack channel.
+// LeaveChannel leaves a slack channel. Bots with their own Slack app leave on their own, bots without one share
+// the app's membership, so the app leaves for all of them.
 //
 //encore:api private method=POST path=/slack/channels/:channelID/leave
 func (s *Service) LeaveChannel(ctx context.Context, channelID string, bot *botdb.Bot) error {
-	_, err := s.client.LeaveConversationContext(ctx, channelID)
+	// The app can't leave direct message conversations
+	if isDM(channelID) {
+		return nil
+	}
+	client, err := s.botClient(ctx, bot)
+	if err != nil {
+		return errors.Wrap(err, "get bot client")
+	} else if client == nil {
+		client = s.client
+	}
+	_, err = client.LeaveConversationContext(ctx, channelID)
 	if err != nil {
 		return errors.Wrap(err, "leave conversation")
 	}
 	return nil
 }
 
-// JoinChannel joins a slack channel.
+// JoinChannel joins a slack channel. The shared app always joins as it receives the events of the channel, bots
+// with their own Slack app join as well.
 //
 //encore:api private method=POST path=/slack/channels/:channelID/join
 func (s *Service) JoinChannel(ctx context.Context, channelID string, bot *botdb.Bot) error {
+	// The app is always a member of its direct message conversations
+	if isDM(channelID) {
+		return nil
+	}
 	_, _, _, err := s.client.JoinConversationContext(ctx, channelID)
-	return errors.Wrap(err, "join conversation")
+	if err != nil {
+		return errors.Wrap(err, "join conversation")
+	}
+	client, err := s.botClient(ctx, bot)
+	if err != nil {
+		return errors.Wrap(err, "get bot client")
+	} else if client == nil {
+		return nil
+	}
+	_, _, _, err = client.JoinConversationContext(ctx, channelID)
+	return errors.Wrap(err, "join conversation as bot")
 }
 
 // ChannelInfo returns information about a slack channel.
@@ -185,61 +381,206 @@ func (s *Service) ChannelInfo(ctx context.Context, channelID string) (provider.C
 	if err != nil {
 		return provider.ChannelInfo{}, err
 	}
-	return provider.ChannelInfo{
-		Provider: chatdb.ProviderSlack,
-		ID:       channelID,
-		Name:     resp.Name,
-	}, nil
+	return s.toChannelInfo(ctx, resp), nil
 }
 
-// SendMessage sends a message to a slack channel.
+// SendMessage sends a message to a slack channel. Bots with their own Slack app post as themselves, all other bots
+// post through the shared app with their name and avatar.
 //
 //encore:api private method=POST path=/slack/channels/:channelID/messages
 func (s *Service) SendMessage(ctx context.Context, channelID string, req *provider.SendMessageRequest) error {
-	avatar := req.Bot.GetAvatarURL()
-	_, _, err := s.client.PostMessageContext(
-		ctx,
-		channelID,
+	opts := []slack.MsgOption{
 		slack.MsgOptionMetadata(slack.SlackMetadata{
 			EventType: BotMessageEventType,
 			EventPayload: map[string]interface{}{
 				BotIDPayload: req.Bot.ID,
 			},
 		}),
-		slack.MsgOptionUsername(req.Bot.Name),
-		slack.MsgOptionText(req.Content, false),
-		slack.MsgOptionIconURL(avatar))
+		slack.MsgOptionText(provider.RenderAttachments(req.Content, req.Attachments), false),
+	}
+	if req.ThreadID != "" {
+		opts = append(opts, slack.MsgOptionTS(req.ThreadID))
+	}
+	client, err := s.channelClient(ctx, channelID, req.Bot)
+	if err != nil {
+		return errors.Wrap(err, "get bot client")
+	} else if client == s.client {
+		opts = append(opts, slack.MsgOptionUsername(req.Bot.Name), slack.MsgOptionIconURL(req.Bot.GetAvatarURL()))
+	}
+	_, _, err = client.PostMessageContext(ctx, channelID, opts...)
 	return errors.Wrap(err, "post message")
 }
 
+// channelClient returns the client a bot uses in a channel. Direct messages are conversations with the shared app,
+// so bots always use it there.
+func (s *Service) channelClient(ctx context.Context, channelID string, bot *botdb.Bot) (*slack.Client, error) {
+	if isDM(channelID) {
+		return s.client, nil
+	}
+	client, err := s.botClient(ctx, bot)
+	if err != nil || client == nil {
+		return s.client, err
+	}
+	return client, nil
+}
+
+// React adds a reaction to a slack message. Bots without their own Slack app share the app's user, so the reaction
+// is published to the inbox on behalf of the bot that reacted.
+//
+//encore:api private method=POST path=/slack/channels/:channelID/reactions
+func (s *Service) React(ctx context.Context, channelID string, req *provider.ReactRequest) error {
+	name, ok := toSlackEmoji(req.Emoji)
+	if !ok {
+		return errors.Newf("unsupported emoji %q", req.Emoji)
+	}
+	client, err := s.channelClient(ctx, channelID, req.Bot)
+	if err != nil {
+		return errors.Wrap(err, "get bot client")
+	}
+	err = client.AddReactionContext(ctx, name, slack.ItemRef{Channel: channelID, Timestamp: req.MessageID})
+	// Another bot might already have added the same reaction
+	var slackErr slack.SlackErrorResponse
+	if err != nil && !(errors.As(err, &slackErr) && slackErr.Err == "already_reacted") {
+		return errors.Wrap(err, "add reaction")
+	}
+	_, err = provider.InboxTopic.Publish(ctx, &provider.Message{
+		Provider:  chatdb.ProviderSlack,
+		ChannelID: channelID,
+		// Use the same author ID as messages sent by the bot
+		Author: provider.User{
+			ID:    fmt.Sprintf("B-%s", req.Bot.ID),
+			Name:  req.Bot.Name,
+			BotID: req.Bot.ID,
+		},
+		Content:  req.Emoji,
+		Time:     time.Now().UTC(),
+		Type:     provider.MessageTypeReactionAdded,
+		ThreadID: req.ThreadID,
+		ParentID: req.MessageID,
+	})
+	return errors.Wrap(err, "publish reaction")
+}
+
 // ListMessages returns a list of messages in a slack channel.
 //
 //encore:api private method=GET path=/slack/channels/:channelID/messages
 func (s *Service) ListMessages(ctx context.Context, channelID string, req *provider.ListMessagesRequest) (*provider.ListMessagesResponse, error) {
-	resp, err := s.client.GetConversationHistoryContext(ctx, &slack.GetConversationHistoryParameters{
-		ChannelID: channelID,
-		Oldest:    req.FromTimestamp,
-		Limit:     100,
+	limit := req.Limit
+	if limit <= 0 || limit > maxHistoryPageSize {
+		limit = maxHistoryPageSize
+	}
+	var resp *slack.GetConversationHistoryResponse
+	err := retryRateLimited(ctx, func() (err error) {
+		// The cursor is the ts of the oldest message of the previous page, Slack excludes latest and oldest
+		resp, err = s.client.GetConversationHistoryContext(ctx, &slack.GetConversationHistoryParameters{
+			ChannelID: channelID,
+			Oldest:    req.After,
+			Latest:    req.Cursor,
+			Limit:     limit,
+		})
+		return err
 	})
 	if err != nil {
-		return nil, err
+		return nil, errors.Wrap(err, "get conversation history")
 	}
-	var rtn []*provider.Message
+	rtn := &provider.ListMessagesResponse{}
 	for i := len(resp.Messages) - 1; i >= 0; i-- {
-		msg := s.toProviderMessage(resp.Messages[i].Msg, channelID)
-		if msg == nil {
+		msg, err := s.toProviderMessage(ctx, resp.Messages[i].Msg, channelID)
+		if err != nil {
+			return nil, errors.Wrap(err, "convert message")
+		} else if msg == nil {
 			continue
 		}
-		rtn = append(rtn, msg)
+		rtn.Messages = append(rtn.Messages, msg)
 	}
-	return &provider.ListMessagesResponse{Messages: rtn}, nil
+	if resp.HasMore && len(resp.Messages) > 0 {
+		rtn.NextCursor = resp.Messages[len(resp.Messages)-1].Timestamp
+	}
+	return rtn, nil
 }
 
-// toProviderMessage converts a slack message to a provider message.
-func (svc *Service) toProviderMessage(msg slack.Msg, channel provider.ChannelID) *provider.Message {
-	if msg.Text == "" || msg.Type != "message" || msg.Hidden ||
-		!slices.Contains([]string{"", "bot_message"}, msg.SubType) {
-		return nil
+// retryRateLimited calls fn and retries it after the delay requested by Slack if the request was rate limited.
+func retryRateLimited(ctx context.Context, fn func() error) error {
+	for attempt := 0; ; attempt++ {
+		err := fn()
+		var rateLimited *slack.RateLimitedError
+		if !errors.As(err, &rateLimited) || attempt == maxRateLimitRetries {
+			return err
+		}
+		rlog.Warn("slack request rate limited", "retry_after", rateLimited.RetryAfter)
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(rateLimited.RetryAfter):
+		}
+	}
+}
+
+// toChangedMessage converts a message_changed or message_deleted event to a provider message with the
+// edited or deleted type. It returns nil for changes that neither modify the text nor unfurl links, e.g.
+// when a thread gets a new reply.
+func toChangedMessage(msg *Message, channel provider.ChannelID) *provider.Message {
+	switch msg.SubType {
+	case "message_changed":
+		if msg.SubMessage == nil || msg.SubMessage.Text == "" {
+			return nil
+		}
+		links := toLinks(msg.SubMessage.Attachments)
+		if msg.PreviousMessage != nil && msg.PreviousMessage.Text == msg.SubMessage.Text && len(links) == 0 {
+			return nil
+		}
+		return &provider.Message{
+			Provider:    chatdb.ProviderSlack,
+			ProviderID:  msg.SubMessage.Timestamp,
+			ChannelID:   channel,
+			Content:     msg.SubMessage.Text,
+			Time:        time.Now().UTC(),
+			Type:        provider.MessageTypeEdited,
+			Attachments: links,
+		}
+	case "message_deleted":
+		return &provider.Message{
+			Provider:   chatdb.ProviderSlack,
+			ProviderID: msg.DeletedTimestamp,
+			ChannelID:  channel,
+			Time:       time.Now().UTC(),
+			Type:       provider.MessageTypeDeleted,
+		}
+	}
+	return nil
+}
+
+// toReactionMessage converts a reaction_added or reaction_removed event to a provider message. Reactions added by
+// the app itself or by the apps of bots are ignored, as they are published by React on behalf of the bot that
+// reacted.
+func (svc *Service) toReactionMessage(ctx context.Context, ev *slackevents.ReactionAddedEvent) (*provider.Message, error) {
+	if ev.Item.Type != "message" || ev.User == svc.userID {
+		return nil, nil
+	}
+	if app, err := botAppByUserID(ctx, ev.User); err != nil || app != nil {
+		return nil, err
+	}
+	typ := provider.MessageTypeReactionAdded
+	if ev.Type == "reaction_removed" {
+		typ = provider.MessageTypeReactionRemoved
+	}
+	return &provider.Message{
+		Provider:  chatdb.ProviderSlack,
+		ChannelID: ev.Item.Channel,
+		Author:    provider.User{ID: ev.User},
+		Content:   fromSlackEmoji(ev.Reaction),
+		Time:      time.Now().UTC(),
+		Type:      typ,
+		ParentID:  ev.Item.Timestamp,
+	}, nil
+}
+
+// toProviderMessage converts a slack message to a provider message. Messages posted by the apps of bots are
+// attributed to the bot, like messages the bots post through the shared app.
+func (svc *Service) toProviderMessage(ctx context.Context, msg slack.Msg, channel provider.ChannelID) (*provider.Message, error) {
+	if (msg.Text == "" && len(msg.Files) == 0) || msg.Type != "message" || msg.Hidden ||
+		!slices.Contains([]string{"", "bot_message", "thread_broadcast", "file_share"}, msg.SubType) {
+		return nil, nil
 	}
 	author := provider.User{
 		ID:   msg.User,
@@ -259,13 +600,34 @@ func (svc *Service) toProviderMessage(msg slack.Msg, channel provider.ChannelID)
 			}
 		}
 	}
+	if author.BotID == uuid.Nil && msg.BotID != "" && msg.BotID != svc.botID {
+		app, err := botAppBySlackBotID(ctx, msg.BotID)
+		if err != nil {
+			return nil, errors.Wrap(err, "get bot app")
+		} else if app != nil {
+			author.ID = fmt.Sprintf("B-%s", app.BotID)
+			author.BotID = app.BotID
+			if msg.BotProfile != nil {
+				author.Name = msg.BotProfile.Name
+			}
+		}
+	}
 	ts, _ := strconv.ParseFloat(msg.Timestamp, 64)
-	return &provider.Message{
-		Provider:   chatdb.ProviderSlack,
-		ProviderID: msg.ClientMsgID,
-		ChannelID:  channel,
-		Author:     author,
-		Content:    msg.Text,
-		Time:       time.UnixMicro(int64(ts * 1e6)).UTC(),
+	// The ts of a message is unique within a channel and is what Slack uses to reference messages, e.g. a
+	// reply's thread_ts is the ts of the message that started the thread.
+	rtn := &provider.Message{
+		Provider:    chatdb.ProviderSlack,
+		ProviderID:  msg.Timestamp,
+		ChannelID:   channel,
+		Author:      author,
+		Content:     msg.Text,
+		Time:        time.UnixMicro(int64(ts * 1e6)).UTC(),
+		Attachments: toAttachments(ctx, msg),
+	}
+	// The parent message of a thread has its own ts as thread_ts, it's still part of the channel timeline
+	if msg.ThreadTimestamp != "" && msg.ThreadTimestamp != msg.Timestamp {
+		rtn.ThreadID = msg.ThreadTimestamp
+		rtn.ParentID = msg.ThreadTimestamp
 	}
+	return rtn, nil
 }
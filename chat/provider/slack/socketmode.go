@@ -0,0 +1,127 @@
+package slack
+
+import (
+	"context"
+	"encoding/json"
+	"time"
+
+	"github.com/cockroachdb/errors"
+	"github.com/slack-go/slack"
+	"github.com/slack-go/slack/socketmode"
+
+	"encore.dev/rlog"
+)
+
+const (
+	// Bounds for the backoff between socket mode reconnection attempts.
+	minSocketBackoff = time.Second
+	maxSocketBackoff = 2 * time.Minute
+)
+
+// runSocketMode receives events over a Slack Socket Mode websocket. It's an alternative to the public
+// webhook endpoint which is useful when the app is not reachable from the internet.
+// The socketmode client reconnects when Slack asks it to, but gives up if a reconnect fails, so the
+// connection is restarted here with a backoff until the context is cancelled.
+func (svc *Service) runSocketMode(ctx context.Context) {
+	backoff := minSocketBackoff
+	for {
+		client := socketmode.New(svc.client)
+		clientCtx, cancel := context.WithCancel(ctx)
+		go svc.handleSocketEvents(clientCtx, client)
+		started := time.Now()
+		err := client.RunContext(clientCtx)
+		cancel()
+		if ctx.Err() != nil {
+			return
+		}
+		// Reset the backoff if the connection was healthy for a while
+		if time.Since(started) > maxSocketBackoff {
+			backoff = minSocketBackoff
+		}
+		rlog.Warn("slack socket mode disconnected", "error", err, "retry_in", backoff)
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(backoff):
+		}
+		backoff = min(backoff*2, maxSocketBackoff)
+	}
+}
+
+// handleSocketEvents reads events from the socket mode client until the context is cancelled.
+// Every envelope is acknowledged, otherwise Slack will retry the delivery.
+func (svc *Service) handleSocketEvents(ctx context.Context, client *socketmode.Client) {
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case evt, ok := <-client.Events:
+			if !ok {
+				return
+			}
+			switch evt.Type {
+			case socketmode.EventTypeConnected:
+				rlog.Info("slack socket mode connected")
+			case socketmode.EventTypeConnectionError:
+				rlog.Warn("slack socket mode connection error", "error", evt.Data)
+			case socketmode.EventTypeEventsAPI:
+				if evt.Request == nil {
+					continue
+				}
+				client.Ack(*evt.Request)
+				err := svc.handleSocketRequest(ctx, evt.Request)
+				if err != nil {
+					rlog.Error("handle socket mode event", "error", err)
+				}
+			case socketmode.EventTypeSlashCommand:
+				cmd, ok := evt.Data.(slack.SlashCommand)
+				if !ok || evt.Request == nil {
+					continue
+				}
+				// The response to a slash command is sent as the payload of the acknowledgement
+				if resp := svc.handleSlashCommand(ctx, &cmd); resp != nil {
+					client.Ack(*evt.Request, resp)
+				} else {
+					client.Ack(*evt.Request)
+				}
+			case socketmode.EventTypeInteractive:
+				callback, ok := evt.Data.(slack.InteractionCallback)
+				if !ok || evt.Request == nil {
+					continue
+				}
+				client.Ack(*evt.Request)
+				err := svc.handleInteraction(ctx, &callback)
+				if err != nil {
+					rlog.Error("handle socket mode interaction", "error", err)
+				}
+			default:
+				// Acknowledge envelopes we don't handle to stop Slack from retrying them
+				if evt.Request != nil && evt.Request.EnvelopeID != "" {
+					client.Ack(*evt.Request)
+				}
+			}
+		}
+	}
+}
+
+// handleSocketRequest handles an events_api envelope. Its payload has the same shape as the body
+// posted to the webhook, so it's routed through the same handler and deduplicated the same way.
+func (svc *Service) handleSocketRequest(ctx context.Context, req *socketmode.Request) error {
+	var event SlackEvent
+	err := json.Unmarshal(req.Payload, &event)
+	if err != nil {
+		return errors.Wrap(err, "unmarshal payload")
+	}
+	if event.Type != "event_callback" {
+		return nil
+	}
+	if event.EventID != "" && !svc.deliveries.claim(event.EventID, time.Now()) {
+		rlog.Debug("skipping duplicate slack event", "event_id", event.EventID, "retry", req.RetryAttempt)
+		return nil
+	}
+	err = svc.handleEventCallback(ctx, &event)
+	if err != nil {
+		svc.deliveries.release(event.EventID)
+	}
+	return err
+}
@@ -0,0 +1,140 @@
+package slack
+
+import (
+	"net/http"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/cockroachdb/errors"
+)
+
+// The payloads in testdata are recorded requests. slash_command.txt is the example from Slack's
+// documentation on verifying requests, the event payloads are signed with testSigningSecret.
+const testSigningSecret = "e6b19c573432dcc6b075501d51b51bb8"
+
+func TestVerifyRequest(t *testing.T) {
+	tests := []struct {
+		name      string
+		file      string
+		secret    string
+		timestamp string
+		signature string
+		now       time.Time
+		tamper    bool
+		wantErr   error
+	}{
+		{
+			name:      "slack documentation example",
+			file:      "slash_command.txt",
+			secret:    "8f742231b10e8888abcd99yyyzzz85a5",
+			timestamp: "1531420618",
+			signature: "v0=a2114d57b48eac39b9ad189dd8316235a7b4a8d21a10bd27519666489c69b503",
+			now:       time.Unix(1531420618, 0).Add(30 * time.Second),
+		},
+		{
+			name:      "url verification",
+			file:      "url_verification.json",
+			secret:    testSigningSecret,
+			timestamp: "1718000000",
+			signature: "v0=d9fedd6cfd43aa7c9c25090b7b391d82e23711a8aad43f530cffbb576268948a",
+			now:       time.Unix(1718000000, 0),
+		},
+		{
+			name:      "event callback",
+			file:      "event_callback.json",
+			secret:    testSigningSecret,
+			timestamp: "1718000000",
+			signature: "v0=d48586e0cad42bdaca5fbf0bcc9fb8417fb59574f676684faf22a0171013ae90",
+			now:       time.Unix(1718000000, 0).Add(time.Minute),
+		},
+		{
+			name:      "wrong secret",
+			file:      "event_callback.json",
+			secret:    "not-the-signing-secret",
+			timestamp: "1718000000",
+			signature: "v0=d48586e0cad42bdaca5fbf0bcc9fb8417fb59574f676684faf22a0171013ae90",
+			now:       time.Unix(1718000000, 0),
+			wantErr:   errInvalidSignature,
+		},
+		{
+			name:      "tampered body",
+			file:      "event_callback.json",
+			secret:    testSigningSecret,
+			timestamp: "1718000000",
+			signature: "v0=d48586e0cad42bdaca5fbf0bcc9fb8417fb59574f676684faf22a0171013ae90",
+			now:       time.Unix(1718000000, 0),
+			tamper:    true,
+			wantErr:   errInvalidSignature,
+		},
+		{
+			name:      "replayed request",
+			file:      "event_callback.json",
+			secret:    testSigningSecret,
+			timestamp: "1718000000",
+			signature: "v0=d48586e0cad42bdaca5fbf0bcc9fb8417fb59574f676684faf22a0171013ae90",
+			now:       time.Unix(1718000000, 0).Add(10 * time.Minute),
+			wantErr:   errStaleRequest,
+		},
+		{
+			name:      "timestamp in the future",
+			file:      "event_callback.json",
+			secret:    testSigningSecret,
+			timestamp: "1718000000",
+			signature: "v0=d48586e0cad42bdaca5fbf0bcc9fb8417fb59574f676684faf22a0171013ae90",
+			now:       time.Unix(1718000000, 0).Add(-10 * time.Minute),
+			wantErr:   errStaleRequest,
+		},
+		{
+			name:    "missing headers",
+			file:    "event_callback.json",
+			secret:  testSigningSecret,
+			now:     time.Unix(1718000000, 0),
+			wantErr: errMissingSignature,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			body, err := os.ReadFile(filepath.Join("testdata", tt.file))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if tt.tamper {
+				body = append(body, ' ')
+			}
+			header := http.Header{}
+			if tt.timestamp != "" {
+				header.Set("X-Slack-Request-Timestamp", tt.timestamp)
+			}
+			if tt.signature != "" {
+				header.Set("X-Slack-Signature", tt.signature)
+			}
+			err = verifyRequest(header, body, tt.secret, tt.now)
+			if !errors.Is(err, tt.wantErr) {
+				t.Errorf("verifyRequest() error = %v, want %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestDeliveryTracker(t *testing.T) {
+	now := time.Unix(1718000000, 0)
+	tracker := newDeliveryTracker()
+	if !tracker.claim("Ev0PV52K21", now) {
+		t.Fatal("first delivery should be claimed")
+	}
+	if tracker.claim("Ev0PV52K21", now.Add(time.Second)) {
+		t.Error("retry should be deduplicated")
+	}
+	if !tracker.claim("Ev0PV52K22", now) {
+		t.Error("other events should be claimed")
+	}
+	tracker.release("Ev0PV52K21")
+	if !tracker.claim("Ev0PV52K21", now.Add(2*time.Second)) {
+		t.Error("released event should be claimed again")
+	}
+	if !tracker.claim("Ev0PV52K21", now.Add(2*retryWindow)) {
+		t.Error("event should be claimed again after the retry window")
+	}
+}
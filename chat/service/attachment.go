@@ -0,0 +1,59 @@
+package chat
+
+import (
+	"context"
+
+	"github.com/cockroachdb/errors"
+
+	"encore.app/chat/provider"
+	"encore.app/chat/service/db"
+	fns "encore.app/pkg/fns"
+	"encore.dev/types/uuid"
+)
+
+// insertAttachments inserts the attachments of a message, identified by its provider ID, into the database.
+func (svc *Service) insertAttachments(ctx context.Context, channelID db.ChannelID, messageID string, attachments []provider.Attachment) error {
+	q := db.New()
+	for i, a := range attachments {
+		err := q.InsertAttachment(ctx, chatdb.Stdlib(), db.InsertAttachmentParams{
+			Position:    int32(i),
+			Type:        a.Type,
+			Name:        a.Name,
+			URL:         a.URL,
+			MimeType:    a.MimeType,
+			Size:        a.Size,
+			Description: a.Description,
+			Text:        a.Text,
+			ChannelID:   channelID,
+			MessageID:   messageID,
+		})
+		if err != nil {
+			return errors.Wrap(err, "insert attachment")
+		}
+	}
+	return nil
+}
+
+// replaceLinks replaces the link attachments of a message. Providers unfurl links after a message is posted, so the
+// links are updated when the message is edited.
+func (svc *Service) replaceLinks(ctx context.Context, channelID db.ChannelID, messageID string, attachments []provider.Attachment) error {
+	links := fns.Filter(attachments, func(a provider.Attachment) bool { return a.Type == provider.AttachmentTypeLink })
+	if len(links) == 0 {
+		return nil
+	}
+	err := db.New().DeleteLinkAttachments(ctx, chatdb.Stdlib(), db.DeleteLinkAttachmentsParams{
+		ChannelID: channelID,
+		MessageID: messageID,
+	})
+	if err != nil {
+		return errors.Wrap(err, "delete link attachments")
+	}
+	return svc.insertAttachments(ctx, channelID, messageID, links)
+}
+
+// listAttachments returns the attachments of the messages
+func (svc *Service) listAttachments(ctx context.Context, msgs []*db.Message) ([]*db.Attachment, error) {
+	ids := fns.Map(msgs, func(m *db.Message) uuid.UUID { return m.ID })
+	attachments, err := db.New().ListAttachmentsForMessages(ctx, chatdb.Stdlib(), ids)
+	return attachments, errors.Wrap(err, "list attachments")
+}
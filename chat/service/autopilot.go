@@ -0,0 +1,235 @@
+package chat
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+
+	"github.com/cockroachdb/errors"
+
+	botsvc "encore.app/bot"
+	"encore.app/chat/service/db"
+	llmprovider "encore.app/llm/provider"
+	"encore.dev/cron"
+	"encore.dev/rlog"
+	"encore.dev/types/uuid"
+)
+
+// Reasons why an autopilot conversation stopped
+const (
+	AutopilotStoppedManually = "stopped"
+	AutopilotStoppedHuman    = "human"
+	AutopilotStoppedRounds   = "rounds"
+	AutopilotStoppedBudget   = "budget"
+	AutopilotStoppedNoBots   = "no_bots"
+	AutopilotStoppedClosed   = "channel_closed"
+)
+
+// defaultAutopilotPause is the pause between rounds if none is given
+const defaultAutopilotPause = 60 * time.Second
+
+type StartAutopilotRequest struct {
+	// Rounds is the number of times the bots continue the conversation
+	Rounds int32
+	// TokenBudget stops the conversation once the bots have written about this many tokens, 0 is unlimited
+	TokenBudget int32
+	// PauseSeconds is the time between the end of a round and the start of the next one. Rounds are started by a
+	// cron job which runs every minute, so shorter pauses are rounded up.
+	PauseSeconds int32
+	// Topic is an optional topic the bots discuss, e.g. "Is a hot dog a sandwich?"
+	Topic string
+}
+
+// This cron job starts the next round of autopilot conversations
+//
+// This uses Encore's cron feature, learn more: https://encore.dev/docs/primitives/cron-jobs
+var _ = cron.NewJob("autopilot", cron.JobConfig{
+	Title:    "Continue Autopilot Conversations",
+	Every:    1 * cron.Minute,
+	Endpoint: ContinueAutopilots,
+})
+
+// StartAutopilot lets the bots in a channel talk among themselves for a number of rounds or until a token budget
+// is spent. The conversation stops as soon as a human writes in the channel. Starting an autopilot in a channel
+// which already has one restarts it.
+//
+//encore:api public method=POST path=/chat/channels/:channelID/autopilot
+func (svc *Service) StartAutopilot(ctx context.Context, channelID uuid.UUID, req *StartAutopilotRequest) (*db.Autopilot, error) {
+	switch {
+	case req.Rounds <= 0:
+		return nil, errors.New("rounds must be positive")
+	case req.TokenBudget < 0:
+		return nil, errors.New("token budget can't be negative")
+	case req.PauseSeconds < 0:
+		return nil, errors.New("pause can't be negative")
+	}
+	channel, err := svc.GetChannel(ctx, channelID)
+	if err != nil {
+		return nil, errors.Wrap(err, "get channel")
+	}
+	if channel.DmUser != "" {
+		return nil, errors.New("bots can't converse in direct messages")
+	}
+	pause := req.PauseSeconds
+	if pause == 0 {
+		pause = int32(defaultAutopilotPause.Seconds())
+	}
+	autopilot, err := db.New().UpsertAutopilot(ctx, chatdb.Stdlib(), db.UpsertAutopilotParams{
+		ChannelID:    channelID,
+		Topic:        req.Topic,
+		MaxRounds:    req.Rounds,
+		TokenBudget:  req.TokenBudget,
+		PauseSeconds: pause,
+	})
+	if err != nil {
+		return nil, errors.Wrap(err, "upsert autopilot")
+	}
+	err = svc.startAutopilotRound(ctx, autopilot, channel)
+	if err != nil {
+		return nil, err
+	}
+	return svc.GetAutopilot(ctx, channelID)
+}
+
+// StopAutopilot stops the autopilot conversation in a channel.
+//
+//encore:api public method=DELETE path=/chat/channels/:channelID/autopilot
+func (svc *Service) StopAutopilot(ctx context.Context, channelID uuid.UUID) error {
+	return svc.stopAutopilot(ctx, channelID, AutopilotStoppedManually)
+}
+
+// GetAutopilot returns the latest autopilot conversation of a channel.
+//
+//encore:api public method=GET path=/chat/channels/:channelID/autopilot
+func (svc *Service) GetAutopilot(ctx context.Context, channelID uuid.UUID) (*db.Autopilot, error) {
+	autopilot, err := db.New().GetAutopilot(ctx, chatdb.Stdlib(), channelID)
+	return autopilot, errors.Wrap(err, "get autopilot")
+}
+
+// ContinueAutopilots starts the next round of all autopilot conversations whose pause has passed, and stops the
+// ones which ran out of rounds or tokens.
+//
+//encore:api private
+func (svc *Service) ContinueAutopilots(ctx context.Context) error {
+	autopilots, err := db.New().ListActiveAutopilots(ctx, chatdb.Stdlib())
+	if err != nil {
+		return errors.Wrap(err, "list active autopilots")
+	}
+	now := time.Now().UTC()
+	for _, autopilot := range autopilots {
+		// A failing autopilot shouldn't block the others
+		if err := svc.continueAutopilot(ctx, autopilot, now); err != nil {
+			rlog.Error("continue autopilot", "channel", autopilot.ChannelID, "error", err)
+		}
+	}
+	return nil
+}
+
+// continueAutopilot starts the next round of an autopilot conversation if its pause has passed, or stops it if it
+// ran out of rounds or tokens, or its channel is gone.
+func (svc *Service) continueAutopilot(ctx context.Context, autopilot *db.Autopilot, now time.Time) error {
+	// The pause starts when the bots are done with the previous round
+	last := autopilot.RoundStarted
+	latest, err := db.New().LatestBotMessageInChannel(ctx, chatdb.Stdlib(), autopilot.ChannelID)
+	if err != nil && !errors.Is(err, sql.ErrNoRows) {
+		return errors.Wrap(err, "latest bot message in channel")
+	} else if err == nil && latest.Timestamp.After(last) {
+		last = latest.Timestamp
+	}
+	if now.Sub(last) < time.Duration(autopilot.PauseSeconds)*time.Second {
+		return nil
+	}
+	switch {
+	case autopilot.Rounds >= autopilot.MaxRounds:
+		return svc.stopAutopilot(ctx, autopilot.ChannelID, AutopilotStoppedRounds)
+	case autopilot.TokenBudget > 0 && autopilot.Tokens >= autopilot.TokenBudget:
+		return svc.stopAutopilot(ctx, autopilot.ChannelID, AutopilotStoppedBudget)
+	}
+	channel, err := svc.GetChannel(ctx, autopilot.ChannelID)
+	if errors.Is(err, sql.ErrNoRows) {
+		return svc.stopAutopilot(ctx, autopilot.ChannelID, AutopilotStoppedClosed)
+	} else if err != nil {
+		return errors.Wrap(err, "get channel")
+	}
+	settings, err := svc.GetChannelSettings(ctx, channel.ID)
+	if err != nil {
+		return errors.Wrap(err, "get channel settings")
+	}
+	// Muted channels pause the autopilot, it continues once e.g. the quiet hours are over
+	if reason, err := svc.botsMuted(ctx, settings, now); err != nil {
+		return errors.Wrap(err, "check channel settings")
+	} else if reason != "" {
+		return nil
+	}
+	return svc.startAutopilotRound(ctx, autopilot, channel)
+}
+
+// startAutopilotRound asks the bots in the channel to continue the conversation. The first round starts the
+// discussion of the topic, if any.
+func (svc *Service) startAutopilotRound(ctx context.Context, autopilot *db.Autopilot, channel *db.Channel) error {
+	q := db.New()
+	botIDs, err := q.ListBotsInChannel(ctx, chatdb.Stdlib(), channel.ID)
+	if err != nil {
+		return errors.Wrap(err, "list bots in channel")
+	}
+	if len(botIDs) == 0 {
+		return svc.stopAutopilot(ctx, channel.ID, AutopilotStoppedNoBots)
+	}
+	err = q.StartAutopilotRound(ctx, chatdb.Stdlib(), channel.ID)
+	if err != nil {
+		return errors.Wrap(err, "start autopilot round")
+	}
+	if autopilot.Rounds == 0 && autopilot.Topic != "" {
+		return svc.InstructBotInChannel(ctx, channel.ID, &InstructRequest{
+			Bots:        botIDs,
+			Instruction: fmt.Sprintf("Start a discussion between the characters about: %s", autopilot.Topic),
+		})
+	}
+	bots, err := botsvc.List(ctx, &botsvc.ListBotRequest{IDs: botIDs})
+	if err != nil {
+		return errors.Wrap(err, "list bots")
+	}
+	err = svc.publishLLMTasks(ctx, llmprovider.TaskTypeContinue, bots.Bots, channel, "", "")
+	return errors.Wrap(err, "publish llm task")
+}
+
+// stopAutopilot stops the autopilot conversation in a channel, if there is one
+func (svc *Service) stopAutopilot(ctx context.Context, channelID uuid.UUID, reason string) error {
+	n, err := db.New().StopAutopilot(ctx, chatdb.Stdlib(), db.StopAutopilotParams{
+		StopReason: reason,
+		ChannelID:  channelID,
+	})
+	if err != nil {
+		return errors.Wrap(err, "stop autopilot")
+	}
+	if n > 0 {
+		rlog.Info("autopilot stopped", "channel", channelID, "reason", reason)
+	}
+	return nil
+}
+
+// hasActiveAutopilot returns true if an autopilot conversation is running in the channel
+func (svc *Service) hasActiveAutopilot(ctx context.Context, channelID uuid.UUID) (bool, error) {
+	autopilot, err := db.New().GetAutopilot(ctx, chatdb.Stdlib(), channelID)
+	if errors.Is(err, sql.ErrNoRows) {
+		return false, nil
+	} else if err != nil {
+		return false, errors.Wrap(err, "get autopilot")
+	}
+	return !autopilot.Stopped.Valid, nil
+}
+
+// countAutopilotTokens adds the estimated tokens of sent bot messages to the budget of the channel's autopilot
+func (svc *Service) countAutopilotTokens(ctx context.Context, channelID uuid.UUID, tokens int32) error {
+	err := db.New().AddAutopilotTokens(ctx, chatdb.Stdlib(), db.AddAutopilotTokensParams{
+		Tokens:    tokens,
+		ChannelID: channelID,
+	})
+	return errors.Wrap(err, "add autopilot tokens")
+}
+
+// estimateTokens estimates the number of tokens of a text. A token is about 4 characters of English text.
+func estimateTokens(content string) int32 {
+	return int32(len(content)+3) / 4
+}
@@ -0,0 +1,177 @@
+package chat
+
+import (
+	"context"
+	"database/sql"
+	"time"
+
+	"github.com/cockroachdb/errors"
+
+	"encore.app/chat/provider"
+	"encore.app/chat/service/client"
+	"encore.app/chat/service/db"
+	fns "encore.app/pkg/fns"
+	"encore.dev/rlog"
+)
+
+// historyPageSize is the number of messages requested per page when loading the history of a channel
+const historyPageSize = 100
+
+// backfillChannel loads the message history of a channel from its provider into the database. If the history has
+// been loaded before, it first catches up on the messages posted since the newest stored message. It then continues
+// loading older messages from the stored checkpoint until the configured depth or the start of the channel is
+// reached. The checkpoint is updated after every page, so an interrupted backfill is resumed where it left off.
+func (svc *Service) backfillChannel(ctx context.Context, channel *db.Channel) error {
+	q := db.New()
+	prov, ok := svc.providers[channel.Provider]
+	if !ok {
+		return errors.New("provider not found")
+	}
+	cc := prov.GetChannelClient(ctx, channel.ProviderID)
+	if channel.Provider == db.ProviderSlack {
+		if err := svc.repairSlackMessageIDs(ctx, channel, cc); err != nil {
+			return errors.Wrap(err, "repair slack message IDs")
+		}
+	}
+	depth := cfg.BackfillDepth()
+	checkpoint, err := q.GetBackfill(ctx, chatdb.Stdlib(), channel.ID)
+	if errors.Is(err, sql.ErrNoRows) {
+		checkpoint = &db.Backfill{ChannelID: channel.ID}
+	} else if err != nil {
+		return errors.Wrap(err, "get backfill")
+	} else {
+		latest, err := q.LatestMessageInChannel(ctx, chatdb.Stdlib(), channel.ID)
+		if err != nil && !errors.Is(err, sql.ErrNoRows) {
+			return errors.Wrap(err, "latest message in channel")
+		}
+		if err == nil {
+			after := latest.ProviderID
+			// Slack only needs a time to load the messages after, so messages without a ts work too, e.g. legacy ones
+			if channel.Provider == db.ProviderSlack && slackTimestamp(after).IsZero() {
+				after = formatSlackTimestamp(latest.Timestamp)
+			}
+			err = svc.loadHistory(ctx, channel, cc, after, "", depth, nil)
+			if err != nil {
+				return errors.Wrap(err, "catch up on history")
+			}
+		}
+	}
+	if checkpoint.Completed || int(checkpoint.Messages) >= depth {
+		return nil
+	}
+	err = svc.loadHistory(ctx, channel, cc, "", checkpoint.NextCursor, depth-int(checkpoint.Messages), func(cursor string, n int) error {
+		checkpoint.NextCursor = cursor
+		checkpoint.Messages += int32(n)
+		checkpoint.Completed = cursor == "" || int(checkpoint.Messages) >= depth
+		return q.UpsertBackfill(ctx, chatdb.Stdlib(), db.UpsertBackfillParams{
+			ChannelID:  channel.ID,
+			NextCursor: checkpoint.NextCursor,
+			Messages:   checkpoint.Messages,
+			Completed:  checkpoint.Completed,
+		})
+	})
+	return errors.Wrap(err, "backfill history")
+}
+
+// loadHistory pages through the history of a channel from cursor towards older messages, and inserts the messages
+// into the database. It stops at the message with the provider ID after, at the start of the channel, at the
+// retention of the channel or when limit messages were loaded. onPage is called after every page with the cursor of
+// the next page and the number of messages in the page. The cursor is empty if there are no more pages to load.
+func (svc *Service) loadHistory(ctx context.Context, channel *db.Channel, cc client.ChannelClient, after, cursor string, limit int, onPage func(cursor string, n int) error) error {
+	settings, err := svc.GetChannelSettings(ctx, channel.ID)
+	if err != nil {
+		return errors.Wrap(err, "get channel settings")
+	}
+	// Messages older than the retention would be deleted by the next purge, or were deleted already
+	cutoff := retentionCutoff(settings.RetentionDays, channel.Provider, time.Now().UTC())
+	for loaded := 0; loaded < limit; {
+		resp, err := cc.ListMessages(ctx, &provider.ListMessagesRequest{
+			After:  after,
+			Cursor: cursor,
+			Limit:  min(historyPageSize, limit-loaded),
+		})
+		if err != nil {
+			return errors.Wrap(err, "list messages")
+		}
+		msgs := resp.Messages
+		if !cutoff.IsZero() {
+			msgs = fns.Filter(msgs, func(msg *provider.Message) bool { return !msg.Time.Before(cutoff) })
+		}
+		_, err = svc.handleProviderMessages(ctx, channel.Provider, msgs...)
+		if err != nil {
+			return errors.Wrap(err, "handle provider messages")
+		}
+		loaded += len(resp.Messages)
+		cursor = resp.NextCursor
+		if len(msgs) < len(resp.Messages) {
+			// The pages are ordered from new to old, so the next pages only have expired messages
+			cursor = ""
+		}
+		if onPage != nil {
+			if err := onPage(cursor, len(resp.Messages)); err != nil {
+				return errors.Wrap(err, "update checkpoint")
+			}
+		}
+		if cursor == "" {
+			return nil
+		}
+	}
+	return nil
+}
+
+// repairSlackMessageIDs replaces the IDs of Slack messages stored before the ts was used as their provider ID, so
+// their edits, deletions, reactions and replies are matched. It loads the history back to the oldest of them and
+// matches the messages by their time, which is derived from the ts. Messages which aren't found, e.g. deleted
+// messages and thread replies, are marked as legacy. Each channel is only repaired once, the channels which need it
+// were listed by a migration.
+func (svc *Service) repairSlackMessageIDs(ctx context.Context, channel *db.Channel, cc client.ChannelClient) error {
+	q := db.New()
+	pending, err := q.SlackIDRepairPending(ctx, chatdb.Stdlib(), channel.ID)
+	if err != nil {
+		return errors.Wrap(err, "get repair")
+	} else if !pending {
+		return nil
+	}
+	oldest, err := q.OldestLegacySlackMessage(ctx, chatdb.Stdlib(), channel.ID)
+	if errors.Is(err, sql.ErrNoRows) {
+		return errors.Wrap(q.CompleteSlackIDRepair(ctx, chatdb.Stdlib(), channel.ID), "complete repair")
+	} else if err != nil {
+		return errors.Wrap(err, "oldest legacy message")
+	}
+	after := formatSlackTimestamp(oldest.Add(-time.Microsecond))
+	var repaired int64
+	for cursor := ""; ; {
+		resp, err := cc.ListMessages(ctx, &provider.ListMessagesRequest{
+			After:  after,
+			Cursor: cursor,
+			Limit:  historyPageSize,
+		})
+		if err != nil {
+			return errors.Wrap(err, "list messages")
+		}
+		for _, msg := range resp.Messages {
+			n, err := q.SetLegacySlackMessageID(ctx, chatdb.Stdlib(), db.SetLegacySlackMessageIDParams{
+				ProviderID: msg.ProviderID,
+				ChannelID:  channel.ID,
+				Timestamp:  msg.Time,
+			})
+			if err != nil {
+				return errors.Wrap(err, "set message ID")
+			}
+			repaired += n
+		}
+		cursor = resp.NextCursor
+		if cursor == "" {
+			break
+		}
+	}
+	missing, err := q.MarkLegacySlackMessages(ctx, chatdb.Stdlib(), channel.ID)
+	if err != nil {
+		return errors.Wrap(err, "mark legacy messages")
+	}
+	if err := q.CompleteSlackIDRepair(ctx, chatdb.Stdlib(), channel.ID); err != nil {
+		return errors.Wrap(err, "complete repair")
+	}
+	rlog.Info("repaired slack message IDs", "channel", channel.ID, "repaired", repaired, "missing", missing)
+	return nil
+}
@@ -2,6 +2,7 @@ package chat
 
 import (
 	"context"
+	"database/sql"
 
 	"github.com/cockroachdb/errors"
 
@@ -10,6 +11,7 @@ import (
 	"encore.app/chat/provider"
 	"encore.app/chat/service/db"
 	provider2 "encore.app/llm/provider"
+	llm "encore.app/llm/service"
 	"encore.dev/rlog"
 	"encore.dev/types/uuid"
 )
@@ -103,11 +105,12 @@ func (svc *Service) AddBotToChannel(ctx context.Context, channelID uuid.UUID, bo
 	if err != nil {
 		return errors.Wrap(err, "join channel")
 	}
-	_, err = svc.loadChannelHistory(ctx, c)
+	// Load the history before the bot introduces itself, so it knows what the channel is about
+	err = svc.backfillChannel(ctx, c)
 	if err != nil {
-		return errors.Wrap(err, "load channel history")
+		return errors.Wrap(err, "backfill channel")
 	}
-	err = svc.publishLLMTasks(ctx, provider2.TaskTypeJoin, []*botdb.Bot{b}, c, "")
+	err = svc.publishLLMTasks(ctx, provider2.TaskTypeJoin, []*botdb.Bot{b}, c, "", "")
 	if err != nil {
 		return errors.Wrap(err, "publish chat event")
 	}
@@ -151,7 +154,7 @@ func (svc *Service) RemoveBotFromChannel(ctx context.Context, channelID uuid.UUI
 		return errors.Wrap(err, "get bot")
 	}
 
-	err = svc.publishLLMTasks(ctx, provider2.TaskTypeLeave, []*botdb.Bot{bot}, channel, "")
+	err = svc.publishLLMTasks(ctx, provider2.TaskTypeLeave, []*botdb.Bot{bot}, channel, "", "")
 	if err != nil {
 		return errors.Wrap(err, "publish chat event")
 	}
@@ -159,7 +162,7 @@ func (svc *Service) RemoveBotFromChannel(ctx context.Context, channelID uuid.UUI
 }
 
 // initChannels loads all channels from all providers and inserts them into the database.
-// It will also load the history of all channels with bots.
+// It will also start loading the history of all channels with bots in the background.
 func (svc *Service) initChannels(ctx context.Context) error {
 	for typ, provider := range svc.providers {
 		channels, err := provider.ListChannels(ctx)
@@ -178,25 +181,141 @@ func (svc *Service) initChannels(ctx context.Context) error {
 	if err != nil {
 		return errors.Wrap(err, "list channels with bots")
 	}
-	for _, pc := range botChannels {
-		if _, err := svc.loadChannelHistory(ctx, pc); err != nil {
-			rlog.Warn("load channel history failed", "channel", pc.ID, "err", err)
+	// Backfilling can take a while for busy channels, it's resumed where it left off if it's interrupted
+	go func() {
+		for _, pc := range botChannels {
+			if err := svc.backfillChannel(ctx, pc); err != nil {
+				rlog.Warn("backfill channel failed", "channel", pc.ID, "err", err)
+			}
+		}
+	}()
+	return nil
+}
+
+// ProcessChannelEvent updates the channels in the database when the channels of a provider change, e.g. when the
+// bot joins or leaves a server, or a channel is renamed, archived or deleted.
+//
+//encore:api private path=/chat/events/provider/channel-event method=POST
+func (svc *Service) ProcessChannelEvent(ctx context.Context, event *provider.ChannelEvent) error {
+	switch event.Type {
+	case provider.ChannelEventUpserted, provider.ChannelEventRestored:
+		for _, channel := range event.Channels {
+			if event.Type == provider.ChannelEventRestored {
+				err := db.New().RestoreChannel(ctx, chatdb.Stdlib(), db.RestoreChannelParams{
+					ProviderID: channel.ID,
+					Provider:   channel.Provider,
+				})
+				if err != nil {
+					return errors.Wrap(err, "restore channel")
+				}
+			}
+			dbChannel, err := svc.insertChannel(ctx, channel)
+			if err != nil {
+				return errors.Wrap(err, "insert channel")
+			}
+			if event.Type == provider.ChannelEventRestored {
+				if err := svc.resumeChannelSchedules(ctx, dbChannel.ID); err != nil {
+					return errors.Wrap(err, "resume schedules")
+				}
+			}
+		}
+	case provider.ChannelEventArchived, provider.ChannelEventDeleted:
+		for _, channel := range event.Channels {
+			dbChannel, err := db.New().GetChannelByProviderId(ctx, chatdb.Stdlib(), db.GetChannelByProviderIdParams{
+				ProviderID: channel.ID,
+				Provider:   event.Provider,
+			})
+			if errors.Is(err, sql.ErrNoRows) {
+				continue
+			} else if err != nil {
+				return errors.Wrap(err, "get channel")
+			}
+			if err := svc.closeChannel(ctx, dbChannel, event.Type); err != nil {
+				return errors.Wrap(err, "close channel")
+			}
 		}
+	case provider.ChannelEventGuildRemoved:
+		// Channels without a server, e.g. direct messages, have an empty guild ID
+		if event.GuildID == "" {
+			return errors.New("guild ID is required")
+		}
+		channels, err := db.New().ListGuildChannels(ctx, chatdb.Stdlib(), db.ListGuildChannelsParams{
+			Provider: event.Provider,
+			GuildID:  event.GuildID,
+		})
+		if err != nil {
+			return errors.Wrap(err, "list guild channels")
+		}
+		for _, channel := range channels {
+			if err := svc.closeChannel(ctx, channel, event.Type); err != nil {
+				return errors.Wrap(err, "close channel")
+			}
+		}
+		err = db.New().DeleteGuildChannels(ctx, chatdb.Stdlib(), db.DeleteGuildChannelsParams{
+			Provider: event.Provider,
+			GuildID:  event.GuildID,
+		})
+		if err != nil {
+			return errors.Wrap(err, "delete guild channels")
+		}
+	default:
+		rlog.Warn("unknown channel event", "type", event.Type)
 	}
 	return nil
 }
 
 // insertChannel inserts a channel into the database. It's triggered when a new channel is discovered from
-// a chat provider.
+// a chat provider. Archived and deleted channels are updated, but stay deleted.
 func (svc *Service) insertChannel(ctx context.Context, channel provider.ChannelInfo) (*db.Channel, error) {
 	queries := db.New()
 	dbChannel, err := queries.UpsertChannel(ctx, chatdb.Stdlib(), db.UpsertChannelParams{
 		ProviderID: channel.ID,
 		Provider:   channel.Provider,
 		Name:       channel.Name,
+		DmUser:     channel.DMUser,
+		GuildID:    channel.GuildID,
 	})
 	if err != nil {
 		return nil, errors.Wrap(err, "upsert channel")
 	}
 	return dbChannel, nil
 }
+
+// closeChannel removes the bots from a channel which was archived or deleted, or whose server the bot left, and
+// deletes it. Its schedules, autopilot and scenario are stopped and running AI tasks are cancelled, so the bots
+// don't write into a channel which is gone. Unlike RemoveBotFromChannel, the bots don't say goodbye. The schedules
+// are enabled again if the channel is restored, the bots have to be added again.
+func (svc *Service) closeChannel(ctx context.Context, channel *db.Channel, reason string) error {
+	q := db.New()
+	bots, err := q.RemoveChannelBots(ctx, chatdb.Stdlib(), channel.ID)
+	if err != nil {
+		return errors.Wrap(err, "remove bots")
+	}
+	if err := q.DisableChannelSchedules(ctx, chatdb.Stdlib(), channel.ID); err != nil {
+		return errors.Wrap(err, "disable schedules")
+	}
+	if err := svc.stopAutopilot(ctx, channel.ID, AutopilotStoppedClosed); err != nil {
+		return err
+	}
+	run, err := q.GetScenarioRun(ctx, chatdb.Stdlib(), channel.ID)
+	if err != nil && !errors.Is(err, sql.ErrNoRows) {
+		return errors.Wrap(err, "get scenario run")
+	} else if err == nil && run.Status != ScenarioCompleted && run.Status != ScenarioStopped {
+		err = q.UpdateScenarioRun(ctx, chatdb.Stdlib(), db.UpdateScenarioRunParams{
+			Beat:      run.Beat,
+			Status:    ScenarioStopped,
+			ChannelID: channel.ID,
+		})
+		if err != nil {
+			return errors.Wrap(err, "stop scenario")
+		}
+	}
+	if err := llm.CancelChannelTasks(ctx, channel.ID); err != nil {
+		return errors.Wrap(err, "cancel tasks")
+	}
+	if err := q.DeleteChannel(ctx, chatdb.Stdlib(), channel.ID); err != nil {
+		return errors.Wrap(err, "delete channel")
+	}
+	rlog.Info("channel closed", "channel", channel.ID, "name", channel.Name, "reason", reason, "bots", bots)
+	return nil
+}
@@ -5,7 +5,6 @@ import (
 
 	botdb "encore.app/bot/db"
 	"encore.app/chat/provider"
-	"encore.app/chat/service/db"
 	"encore.dev/types/uuid"
 )
 
@@ -17,16 +16,20 @@ type Client interface {
 	GetChannelClient(ctx context.Context, id provider.ChannelID) ChannelClient
 	// GetUser gets a user by ID
 	GetUser(ctx context.Context, id provider.UserID) (*provider.User, error)
+	// Respond responds to a command issued by a user
+	Respond(ctx context.Context, resp *provider.CommandResponse) error
 }
 
 // ChannelClient is a client for a specific channel in a provider
 type ChannelClient interface {
 	// Send sends a message to the channel
 	Send(ctx context.Context, req *provider.SendMessageRequest) error
+	// React adds an emoji reaction from a bot to a message in the channel
+	React(ctx context.Context, req *provider.ReactRequest) error
 
 	Typing(ctx context.Context, botID uuid.UUID) error
-	// ListMessages lists messages in the channel
-	ListMessages(ctx context.Context, from *db.Message) ([]*provider.Message, error)
+	// ListMessages lists a page of the message history of the channel
+	ListMessages(ctx context.Context, req *provider.ListMessagesRequest) (*provider.ListMessagesResponse, error)
 	// GetInfo gets information about the channel
 	Info(ctx context.Context) (provider.ChannelInfo, error)
 	// Join joins the bot to the channel
@@ -9,7 +9,6 @@ import (
 	"encore.app/chat/provider"
 	"encore.app/chat/provider/discord"
 	"encore.app/chat/service/client"
-	chatdb "encore.app/chat/service/db"
 	"encore.dev/types/uuid"
 )
 
@@ -35,6 +34,10 @@ func (p *Client) GetUser(ctx context.Context, id provider.UserID) (*provider.Use
 	return discord.GetUser(ctx, id)
 }
 
+func (p *Client) Respond(ctx context.Context, resp *provider.CommandResponse) error {
+	return discord.RespondToCommand(ctx, resp)
+}
+
 func (p *Client) GetChannelClient(ctx context.Context, id provider.ChannelID) client.ChannelClient {
 	return &Channel{
 		Client:    p,
@@ -55,16 +58,13 @@ func (c *Channel) Send(ctx context.Context, req *provider.SendMessageRequest) er
 	return discord.SendMessage(ctx, c.channelID, req)
 }
 
-func (c *Channel) ListMessages(ctx context.Context, from *chatdb.Message) ([]*provider.Message, error) {
-	fromID := ""
-	if from != nil {
-		fromID = from.ProviderID
-	}
-	resp, err := discord.ListMessages(ctx, c.channelID, &provider.ListMessagesRequest{FromMessageID: fromID})
-	if err != nil {
-		return nil, errors.Wrap(err, "list messages")
-	}
-	return resp.Messages, nil
+func (c *Channel) React(ctx context.Context, req *provider.ReactRequest) error {
+	return discord.React(ctx, c.channelID, req)
+}
+
+func (c *Channel) ListMessages(ctx context.Context, req *provider.ListMessagesRequest) (*provider.ListMessagesResponse, error) {
+	resp, err := discord.ListMessages(ctx, c.channelID, req)
+	return resp, errors.Wrap(err, "list messages")
 }
 
 func (c *Channel) Info(ctx context.Context) (provider.ChannelInfo, error) {
@@ -3,6 +3,8 @@ package local
 import (
 	"context"
 
+	"github.com/cockroachdb/errors"
+
 	botdb "encore.app/bot/db"
 	"encore.app/chat/provider"
 	"encore.app/chat/provider/local"
@@ -32,6 +34,10 @@ func (p *Client) GetUser(ctx context.Context, id provider.UserID) (*provider.Use
 	}, nil
 }
 
+func (p *Client) Respond(ctx context.Context, resp *provider.CommandResponse) error {
+	return errors.New("local chat doesn't support commands")
+}
+
 func (p *Client) GetChannelClient(ctx context.Context, id provider.ChannelID) client.ChannelClient {
 	return &Channel{
 		Client:    p,
@@ -52,8 +58,13 @@ func (c *Channel) Send(ctx context.Context, req *provider.SendMessageRequest) er
 	return local.SendMessage(ctx, c.channelID, req)
 }
 
-func (c *Channel) ListMessages(ctx context.Context, from *chatdb.Message) ([]*provider.Message, error) {
-	return nil, nil
+func (c *Channel) React(ctx context.Context, req *provider.ReactRequest) error {
+	return local.React(ctx, c.channelID, req)
+}
+
+// ListMessages returns no messages, the local chat stores its messages in the chat database.
+func (c *Channel) ListMessages(ctx context.Context, req *provider.ListMessagesRequest) (*provider.ListMessagesResponse, error) {
+	return &provider.ListMessagesResponse{}, nil
 }
 
 func (c *Channel) Info(ctx context.Context) (provider.ChannelInfo, error) {
@@ -2,7 +2,6 @@ package slack
 
 import (
 	"context"
-	"fmt"
 
 	"github.com/cockroachdb/errors"
 
@@ -10,7 +9,6 @@ import (
 	"encore.app/chat/provider"
 	"encore.app/chat/provider/slack"
 	"encore.app/chat/service/client"
-	chatdb "encore.app/chat/service/db"
 	"encore.dev/types/uuid"
 )
 
@@ -36,6 +34,10 @@ func (s *Client) GetUser(ctx context.Context, id provider.UserID) (*provider.Use
 	return slack.GetUser(ctx, id)
 }
 
+func (s *Client) Respond(ctx context.Context, resp *provider.CommandResponse) error {
+	return slack.RespondToCommand(ctx, resp)
+}
+
 func (s *Client) GetChannelClient(ctx context.Context, id provider.ChannelID) client.ChannelClient {
 	return &Channel{
 		channelID: id,
@@ -54,16 +56,13 @@ func (c *Channel) Send(ctx context.Context, req *provider.SendMessageRequest) er
 	return slack.SendMessage(ctx, c.channelID, req)
 }
 
-func (c *Channel) ListMessages(ctx context.Context, from *chatdb.Message) ([]*provider.Message, error) {
-	fromTimestamp := ""
-	if from != nil {
-		fromTimestamp = fmt.Sprintf("%f", float64(from.Timestamp.UnixMicro()/1e6))
-	}
-	resp, err := slack.ListMessages(ctx, c.channelID, &provider.ListMessagesRequest{FromTimestamp: fromTimestamp})
-	if err != nil {
-		return nil, errors.Wrap(err, "list messages")
-	}
-	return resp.Messages, nil
+func (c *Channel) React(ctx context.Context, req *provider.ReactRequest) error {
+	return slack.React(ctx, c.channelID, req)
+}
+
+func (c *Channel) ListMessages(ctx context.Context, req *provider.ListMessagesRequest) (*provider.ListMessagesResponse, error) {
+	resp, err := slack.ListMessages(ctx, c.channelID, req)
+	return resp, errors.Wrap(err, "list messages")
 }
 
 func (c *Channel) Info(ctx context.Context) (provider.ChannelInfo, error) {
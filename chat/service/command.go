@@ -0,0 +1,175 @@
+package chat
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/cockroachdb/errors"
+
+	botsvc "encore.app/bot"
+	botdb "encore.app/bot/db"
+	"encore.app/chat/provider"
+	"encore.app/chat/service/db"
+	fns "encore.app/pkg/fns"
+	"encore.dev/rlog"
+)
+
+// defaultLLM is the LLM provider of bots created with commands if none is specified
+const defaultLLM = "openai"
+
+// ProcessProviderCommand processes a bot management command issued by a user in a chat provider and responds to
+// the user with the result. Failed commands are reported to the user instead of being retried.
+//
+//encore:api private path=/chat/events/provider/command method=POST
+func (svc *Service) ProcessProviderCommand(ctx context.Context, cmd *provider.Command) error {
+	prov, ok := svc.providers[cmd.Provider]
+	if !ok {
+		return errors.New("provider not found")
+	}
+	content, err := svc.runCommand(ctx, cmd)
+	if err != nil {
+		rlog.Warn("command failed", "command", cmd.Name, "error", err)
+		content = fmt.Sprintf("Something went wrong while running the %s command.", cmd.Name)
+	}
+	err = prov.Respond(ctx, &provider.CommandResponse{
+		Token:   cmd.Token,
+		Content: content,
+	})
+	return errors.Wrap(err, "respond to command")
+}
+
+// runCommand runs a command and returns the response for the user. Errors are only returned for unexpected failures,
+// invalid commands are explained in the response.
+func (svc *Service) runCommand(ctx context.Context, cmd *provider.Command) (string, error) {
+	q := db.New()
+	channel, err := svc.getProviderChannel(ctx, cmd.Provider, cmd.ChannelID)
+	if err != nil {
+		return "", errors.Wrap(err, "get channel")
+	}
+	if cmd.Name == provider.CommandCreateBot {
+		return svc.createBot(ctx, cmd)
+	}
+	botIDs, err := q.ListBotsInChannel(ctx, chatdb.Stdlib(), channel.ID)
+	if err != nil {
+		return "", errors.Wrap(err, "list bots in channel")
+	}
+	if cmd.Name == provider.CommandListBots {
+		if len(botIDs) == 0 {
+			return "There are no bots in this channel.", nil
+		}
+		resp, err := botsvc.List(ctx, &botsvc.ListBotRequest{IDs: botIDs})
+		if err != nil {
+			return "", errors.Wrap(err, "list bots")
+		}
+		names := fns.Map(resp.Bots, func(b *botdb.Bot) string { return b.Name })
+		return "Bots in this channel: " + strings.Join(names, ", "), nil
+	}
+	b, err := findBot(ctx, cmd.Bot)
+	if err != nil {
+		return "", errors.Wrap(err, "find bot")
+	} else if b == nil {
+		return fmt.Sprintf("There is no bot named %s.", cmd.Bot), nil
+	}
+	inChannel := slices.Contains(botIDs, b.ID)
+	switch cmd.Name {
+	case provider.CommandAddBot:
+		if inChannel {
+			return fmt.Sprintf("%s is already in this channel.", b.Name), nil
+		}
+		err = svc.AddBotToChannel(ctx, channel.ID, b.ID)
+		if err != nil {
+			return "", errors.Wrap(err, "add bot to channel")
+		}
+		return fmt.Sprintf("Added %s to this channel.", b.Name), nil
+	case provider.CommandRemoveBot:
+		if !inChannel {
+			return fmt.Sprintf("%s isn't in this channel.", b.Name), nil
+		}
+		err = svc.RemoveBotFromChannel(ctx, channel.ID, b.ID)
+		if err != nil {
+			return "", errors.Wrap(err, "remove bot from channel")
+		}
+		return fmt.Sprintf("Removed %s from this channel.", b.Name), nil
+	case provider.CommandInstruct:
+		if !inChannel {
+			return fmt.Sprintf("%s isn't in this channel, add it with the add command first.", b.Name), nil
+		}
+		err = svc.InstructBotInChannel(ctx, channel.ID, &InstructRequest{
+			Bots:        []db.BotID{b.ID},
+			Instruction: cmd.Text,
+		})
+		if err != nil {
+			return "", errors.Wrap(err, "instruct bot")
+		}
+		return fmt.Sprintf("Instructed %s.", b.Name), nil
+	}
+	return fmt.Sprintf("Unknown command %s.", cmd.Name), nil
+}
+
+// createBot creates a new bot from a create command
+func (svc *Service) createBot(ctx context.Context, cmd *provider.Command) (string, error) {
+	existing, err := findBot(ctx, cmd.Bot)
+	if err != nil {
+		return "", errors.Wrap(err, "find bot")
+	} else if existing != nil {
+		return fmt.Sprintf("A bot named %s already exists.", existing.Name), nil
+	}
+	llm := cmd.LLM
+	if llm == "" {
+		llm = defaultLLM
+	}
+	b, err := botsvc.Create(ctx, &botsvc.CreateBotRequest{
+		Name:   cmd.Bot,
+		Prompt: cmd.Text,
+		LLM:    llm,
+	})
+	if err != nil {
+		return "", errors.Wrap(err, "create bot")
+	}
+	return fmt.Sprintf("Created %s: %s", b.Name, b.Profile), nil
+}
+
+// findBot returns the bot with the given name, ignoring case. It returns nil if there is no such bot.
+func findBot(ctx context.Context, name string) (*botdb.Bot, error) {
+	resp, err := botsvc.List(ctx, &botsvc.ListBotRequest{})
+	if err != nil {
+		return nil, errors.Wrap(err, "list bots")
+	}
+	for _, b := range resp.Bots {
+		if strings.EqualFold(b.Name, strings.TrimSpace(name)) {
+			return b, nil
+		}
+	}
+	return nil, nil
+}
+
+// getProviderChannel returns a channel by its provider ID. Channels which haven't been discovered yet are looked up
+// in the provider and inserted into the database.
+func (svc *Service) getProviderChannel(ctx context.Context, providerName db.Provider, channelID provider.ChannelID) (*db.Channel, error) {
+	channel, err := db.New().GetChannelByProviderID(ctx, chatdb.Stdlib(), db.GetChannelByProviderIDParams{
+		ProviderID: channelID,
+		Provider:   providerName,
+	})
+	if !errors.Is(err, sql.ErrNoRows) {
+		return channel, errors.Wrap(err, "get channel")
+	}
+	prov, ok := svc.providers[providerName]
+	if !ok {
+		return nil, errors.New("provider not found")
+	}
+	info, err := prov.GetChannelClient(ctx, channelID).Info(ctx)
+	if err != nil {
+		return nil, errors.Wrap(err, "get channel info")
+	}
+	channel, err = svc.insertChannel(ctx, info)
+	if err != nil {
+		return nil, err
+	}
+	if channel.Deleted.Valid {
+		return nil, errors.New("channel is archived or deleted")
+	}
+	return channel, nil
+}
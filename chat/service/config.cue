@@ -1 +1,14 @@
-InitConversationIntervalMinutes: 20
\ No newline at end of file
+InitConversationIntervalMinutes: 20
+// DefaultSchedule is when bots start conversations in channels without schedules, daily at midnight UTC
+DefaultSchedule: "0 0 * * *"
+// BackfillDepth is the maximum number of messages loaded from the history of a channel
+BackfillDepth: 500
+// RetentionDays is how many days messages are kept per provider, 0 keeps them forever. Channels can override it
+// with the RetentionDays channel setting.
+RetentionDays: {
+	Slack:     0
+	Discord:   0
+	Localchat: 0
+}
+// PurgeDeletedAfterDays is how many days deleted messages, bots and bot memberships are kept before they're purged
+PurgeDeletedAfterDays: 30
@@ -3,33 +3,36 @@ package chat
 import (
 	"context"
 	"database/sql"
+	"slices"
 	"time"
 
 	"github.com/cockroachdb/errors"
 
 	"encore.app/chat/service/db"
 	"encore.dev/config"
-	"encore.dev/cron"
 	"encore.dev/rlog"
 )
 
 type Config struct {
 	InitConversationIntervalMinutes config.Int
+	// DefaultSchedule is a cron expression (UTC) at which bots start conversations in channels without schedules
+	// of their own. Leave it empty to disable it.
+	DefaultSchedule config.String
+	// BackfillDepth is the maximum number of messages loaded from the history of a channel
+	BackfillDepth config.Int
+	// RetentionDays is how many days messages are kept per provider, 0 keeps them forever
+	RetentionDays RetentionConfig
+	// PurgeDeletedAfterDays is how many days deleted messages, bots and bot memberships are kept before they're
+	// purged
+	PurgeDeletedAfterDays config.Int
 }
 
 // This uses Encore Configuration, learn more: https://encore.dev/docs/develop/config
 var cfg = config.Load[*Config]()
 
-// This cron job initiates conversations with users in channels that have not had a conversation in a while
-//
-// This uses Encore's cron feature, learn more: https://encore.dev/docs/primitives/cron-jobs
-var _ = cron.NewJob("initiate-convos", cron.JobConfig{
-	Title:    "Initiate Conversations",
-	Every:    24 * cron.Hour,
-	Endpoint: InitiateConversation,
-})
-
-// InitiateConversation initiates conversations with users in channels that have not had a conversation in a while
+// InitiateConversation initiates conversations with users in channels that have not had a conversation in a while.
+// It's run by RunSchedules at the times of the DefaultSchedule config, channels with schedules of their own are
+// skipped.
 //
 //encore:api private
 func (svc *Service) InitiateConversation(ctx context.Context) error {
@@ -39,7 +42,15 @@ func (svc *Service) InitiateConversation(ctx context.Context) error {
 	if err != nil {
 		return errors.Wrap(err, "list channels with bots")
 	}
+	scheduled, err := q.ListScheduledChannels(ctx, chatdb.Stdlib())
+	if err != nil {
+		return errors.Wrap(err, "list scheduled channels")
+	}
 	for _, channel := range channels {
+		// Bots only answer in direct messages, they never start a conversation there
+		if channel.DmUser != "" || slices.Contains(scheduled, channel.ID) {
+			continue
+		}
 		latest, err := q.LatestBotMessageInChannel(ctx, chatdb.Stdlib(), channel.ID)
 		if err != nil && !errors.Is(err, sql.ErrNoRows) {
 			return errors.Wrap(err, "latest message in channel")
@@ -47,6 +58,15 @@ func (svc *Service) InitiateConversation(ctx context.Context) error {
 		if time.Since(latest.Timestamp) < time.Duration(cfg.InitConversationIntervalMinutes())*time.Minute {
 			continue
 		}
+		settings, err := svc.GetChannelSettings(ctx, channel.ID)
+		if err != nil {
+			return errors.Wrap(err, "get channel settings")
+		}
+		if reason, err := svc.botsMuted(ctx, settings, time.Now().UTC()); err != nil {
+			return errors.Wrap(err, "check channel settings")
+		} else if reason != "" {
+			continue
+		}
 		bots, err := q.ListBotsInChannel(ctx, chatdb.Stdlib(), channel.ID)
 		if err != nil {
 			return errors.Wrap(err, "list bots in channel")
@@ -11,6 +11,29 @@ import (
 	"encore.dev/types/uuid"
 )
 
+const deleteChannel = `-- name: DeleteChannel :exec
+UPDATE channel SET deleted = NOW() WHERE id = $1 AND deleted IS NULL
+`
+
+func (q *Queries) DeleteChannel(ctx context.Context, db DBTX, id uuid.UUID) error {
+	_, err := db.ExecContext(ctx, deleteChannel, id)
+	return err
+}
+
+const deleteGuildChannels = `-- name: DeleteGuildChannels :exec
+UPDATE channel SET deleted = NOW() WHERE provider = $1 AND guild_id = $2 AND deleted IS NULL
+`
+
+type DeleteGuildChannelsParams struct {
+	Provider Provider
+	GuildID  string
+}
+
+func (q *Queries) DeleteGuildChannels(ctx context.Context, db DBTX, arg DeleteGuildChannelsParams) error {
+	_, err := db.ExecContext(ctx, deleteGuildChannels, arg.Provider, arg.GuildID)
+	return err
+}
+
 const getBotChannel = `-- name: GetBotChannel :one
 SELECT bot FROM bot_channel WHERE bot = $1 AND channel = $2 AND deleted IS NULL
 `
@@ -28,7 +51,7 @@ func (q *Queries) GetBotChannel(ctx context.Context, db DBTX, arg GetBotChannelP
 }
 
 const getChannel = `-- name: GetChannel :one
-SELECT id, provider_id, provider, name, deleted FROM channel WHERE id = $1 AND deleted IS NULL
+SELECT id, provider_id, provider, name, deleted, dm_user, guild_id FROM channel WHERE id = $1 AND deleted IS NULL
 `
 
 func (q *Queries) GetChannel(ctx context.Context, db DBTX, id uuid.UUID) (*Channel, error) {
@@ -40,12 +63,14 @@ func (q *Queries) GetChannel(ctx context.Context, db DBTX, id uuid.UUID) (*Chann
 		&i.Provider,
 		&i.Name,
 		&i.Deleted,
+		&i.DmUser,
+		&i.GuildID,
 	)
 	return &i, err
 }
 
 const getChannelByProviderID = `-- name: GetChannelByProviderID :one
-SELECT id, provider_id, provider, name, deleted FROM channel WHERE provider_id = $1 AND provider = $2 AND deleted IS NULL
+SELECT id, provider_id, provider, name, deleted, dm_user, guild_id FROM channel WHERE provider_id = $1 AND provider = $2 AND deleted IS NULL
 `
 
 type GetChannelByProviderIDParams struct {
@@ -62,12 +87,14 @@ func (q *Queries) GetChannelByProviderID(ctx context.Context, db DBTX, arg GetCh
 		&i.Provider,
 		&i.Name,
 		&i.Deleted,
+		&i.DmUser,
+		&i.GuildID,
 	)
 	return &i, err
 }
 
 const getChannelByProviderId = `-- name: GetChannelByProviderId :one
-SELECT id, provider_id, provider, name, deleted FROM channel WHERE provider_id = $1 AND provider = $2 AND deleted IS NULL
+SELECT id, provider_id, provider, name, deleted, dm_user, guild_id FROM channel WHERE provider_id = $1 AND provider = $2 AND deleted IS NULL
 `
 
 type GetChannelByProviderIdParams struct {
@@ -84,6 +111,8 @@ func (q *Queries) GetChannelByProviderId(ctx context.Context, db DBTX, arg GetCh
 		&i.Provider,
 		&i.Name,
 		&i.Deleted,
+		&i.DmUser,
+		&i.GuildID,
 	)
 	return &i, err
 }
@@ -116,7 +145,7 @@ func (q *Queries) ListBotsInChannel(ctx context.Context, db DBTX, channel uuid.U
 }
 
 const listChannels = `-- name: ListChannels :many
-SELECT id, provider_id, provider, name, deleted FROM channel WHERE deleted IS NULL
+SELECT id, provider_id, provider, name, deleted, dm_user, guild_id FROM channel WHERE deleted IS NULL
 `
 
 func (q *Queries) ListChannels(ctx context.Context, db DBTX) ([]*Channel, error) {
@@ -134,6 +163,8 @@ func (q *Queries) ListChannels(ctx context.Context, db DBTX) ([]*Channel, error)
 			&i.Provider,
 			&i.Name,
 			&i.Deleted,
+			&i.DmUser,
+			&i.GuildID,
 		); err != nil {
 			return nil, err
 		}
@@ -149,7 +180,7 @@ func (q *Queries) ListChannels(ctx context.Context, db DBTX) ([]*Channel, error)
 }
 
 const listChannelsByProvider = `-- name: ListChannelsByProvider :many
-SELECT id, provider_id, provider, name, deleted FROM channel WHERE deleted IS NULL AND provider = $1
+SELECT id, provider_id, provider, name, deleted, dm_user, guild_id FROM channel WHERE deleted IS NULL AND provider = $1
 `
 
 func (q *Queries) ListChannelsByProvider(ctx context.Context, db DBTX, provider Provider) ([]*Channel, error) {
@@ -167,6 +198,8 @@ func (q *Queries) ListChannelsByProvider(ctx context.Context, db DBTX, provider
 			&i.Provider,
 			&i.Name,
 			&i.Deleted,
+			&i.DmUser,
+			&i.GuildID,
 		); err != nil {
 			return nil, err
 		}
@@ -185,7 +218,7 @@ const listChannelsWithBots = `-- name: ListChannelsWithBots :many
 WITH channelIds AS (
     SELECT distinct channel as id FROM bot_channel WHERE deleted IS NULL
 )
-SELECT id, provider_id, provider, name, deleted FROM channel WHERE id IN (SELECT id FROM channelIds) AND deleted IS NULL
+SELECT id, provider_id, provider, name, deleted, dm_user, guild_id FROM channel WHERE id IN (SELECT id FROM channelIds) AND deleted IS NULL
 `
 
 func (q *Queries) ListChannelsWithBots(ctx context.Context, db DBTX) ([]*Channel, error) {
@@ -203,6 +236,48 @@ func (q *Queries) ListChannelsWithBots(ctx context.Context, db DBTX) ([]*Channel
 			&i.Provider,
 			&i.Name,
 			&i.Deleted,
+			&i.DmUser,
+			&i.GuildID,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, &i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listGuildChannels = `-- name: ListGuildChannels :many
+SELECT id, provider_id, provider, name, deleted, dm_user, guild_id FROM channel WHERE provider = $1 AND guild_id = $2 AND deleted IS NULL
+`
+
+type ListGuildChannelsParams struct {
+	Provider Provider
+	GuildID  string
+}
+
+func (q *Queries) ListGuildChannels(ctx context.Context, db DBTX, arg ListGuildChannelsParams) ([]*Channel, error) {
+	rows, err := db.QueryContext(ctx, listGuildChannels, arg.Provider, arg.GuildID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := []*Channel{}
+	for rows.Next() {
+		var i Channel
+		if err := rows.Scan(
+			&i.ID,
+			&i.ProviderID,
+			&i.Provider,
+			&i.Name,
+			&i.Deleted,
+			&i.DmUser,
+			&i.GuildID,
 		); err != nil {
 			return nil, err
 		}
@@ -233,6 +308,47 @@ func (q *Queries) RemoveBotChannel(ctx context.Context, db DBTX, arg RemoveBotCh
 	return bot, err
 }
 
+const removeChannelBots = `-- name: RemoveChannelBots :many
+UPDATE bot_channel SET deleted = NOW() WHERE channel = $1 AND deleted IS NULL RETURNING bot
+`
+
+func (q *Queries) RemoveChannelBots(ctx context.Context, db DBTX, channel uuid.UUID) ([]uuid.UUID, error) {
+	rows, err := db.QueryContext(ctx, removeChannelBots, channel)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := []uuid.UUID{}
+	for rows.Next() {
+		var bot uuid.UUID
+		if err := rows.Scan(&bot); err != nil {
+			return nil, err
+		}
+		items = append(items, bot)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const restoreChannel = `-- name: RestoreChannel :exec
+UPDATE channel SET deleted = NULL WHERE provider_id = $1 AND provider = $2
+`
+
+type RestoreChannelParams struct {
+	ProviderID string
+	Provider   Provider
+}
+
+func (q *Queries) RestoreChannel(ctx context.Context, db DBTX, arg RestoreChannelParams) error {
+	_, err := db.ExecContext(ctx, restoreChannel, arg.ProviderID, arg.Provider)
+	return err
+}
+
 const upsertBotChannel = `-- name: UpsertBotChannel :one
 INSERT INTO bot_channel (bot, channel, provider) VALUES ($1, $2, $3) ON CONFLICT (bot, channel) DO UPDATE SET deleted = NULL RETURNING bot
 `
@@ -251,22 +367,30 @@ func (q *Queries) UpsertBotChannel(ctx context.Context, db DBTX, arg UpsertBotCh
 }
 
 const upsertChannel = `-- name: UpsertChannel :one
-INSERT INTO channel (id, provider_id, provider, name)
-SELECT coalesce(id, new_id), $1, $2, $3
+INSERT INTO channel (id, provider_id, provider, name, dm_user, guild_id)
+SELECT coalesce(id, new_id), $1, $2, $3, $4, $5
 FROM (VALUES(gen_random_uuid())) AS data(new_id) LEFT JOIN channel c
 ON c.provider = $2 AND c.provider_id = $1
-ON CONFLICT(provider_id, provider) DO UPDATE SET name = $3
-RETURNING id, provider_id, provider, name, deleted
+ON CONFLICT(provider_id, provider) DO UPDATE SET name = $3, dm_user = $4, guild_id = $5
+RETURNING id, provider_id, provider, name, deleted, dm_user, guild_id
 `
 
 type UpsertChannelParams struct {
 	ProviderID string
 	Provider   Provider
 	Name       string
+	DmUser     string
+	GuildID    string
 }
 
 func (q *Queries) UpsertChannel(ctx context.Context, db DBTX, arg UpsertChannelParams) (*Channel, error) {
-	row := db.QueryRowContext(ctx, upsertChannel, arg.ProviderID, arg.Provider, arg.Name)
+	row := db.QueryRowContext(ctx, upsertChannel,
+		arg.ProviderID,
+		arg.Provider,
+		arg.Name,
+		arg.DmUser,
+		arg.GuildID,
+	)
 	var i Channel
 	err := row.Scan(
 		&i.ID,
@@ -274,6 +398,8 @@ func (q *Queries) UpsertChannel(ctx context.Context, db DBTX, arg UpsertChannelP
 		&i.Provider,
 		&i.Name,
 		&i.Deleted,
+		&i.DmUser,
+		&i.GuildID,
 	)
 	return &i, err
 }
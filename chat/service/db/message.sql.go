@@ -7,16 +7,49 @@ package db
 
 import (
 	"context"
+	"database/sql"
 	"time"
 
 	"encore.dev/types/uuid"
 )
 
+const countBotMessagesSince = `-- name: CountBotMessagesSince :one
+SELECT count(*) FROM message m join "user" u on m.author_id = u.id
+WHERE m.channel_id = $1 AND u.bot_id IS NOT NULL AND m.deleted IS NULL AND m.timestamp > $2
+`
+
+type CountBotMessagesSinceParams struct {
+	ChannelID uuid.UUID
+	Since     time.Time
+}
+
+func (q *Queries) CountBotMessagesSince(ctx context.Context, db DBTX, arg CountBotMessagesSinceParams) (int64, error) {
+	row := db.QueryRowContext(ctx, countBotMessagesSince, arg.ChannelID, arg.Since)
+	var count int64
+	err := row.Scan(&count)
+	return count, err
+}
+
+const deleteMessage = `-- name: DeleteMessage :exec
+UPDATE message SET deleted = NOW()
+WHERE channel_id = $1 AND provider_id = $2 AND deleted IS NULL
+`
+
+type DeleteMessageParams struct {
+	ChannelID  uuid.UUID
+	ProviderID string
+}
+
+func (q *Queries) DeleteMessage(ctx context.Context, db DBTX, arg DeleteMessageParams) error {
+	_, err := db.ExecContext(ctx, deleteMessage, arg.ChannelID, arg.ProviderID)
+	return err
+}
+
 const insertMessage = `-- name: InsertMessage :one
-INSERT INTO message (id, provider_id, channel_id, author_id, content, timestamp)
-VALUES (gen_random_uuid (), $1, $2, $3, $4, $5)
+INSERT INTO message (id, provider_id, channel_id, author_id, content, timestamp, thread_id, parent_id)
+VALUES (gen_random_uuid (), $1, $2, $3, $4, $5, $6, $7)
 ON CONFLICT (channel_id, author_id, content, timestamp) DO NOTHING
-RETURNING id, provider_id, channel_id, author_id, content, timestamp, deleted
+RETURNING id, provider_id, channel_id, author_id, content, timestamp, deleted, thread_id, parent_id
 `
 
 type InsertMessageParams struct {
@@ -25,17 +58,33 @@ type InsertMessageParams struct {
 	AuthorID   uuid.UUID
 	Content    string
 	Timestamp  time.Time
+	ThreadID   string
+	ParentID   string
+}
+
+type InsertMessageRow struct {
+	ID         uuid.UUID
+	ProviderID string
+	ChannelID  uuid.UUID
+	AuthorID   uuid.UUID
+	Content    string
+	Timestamp  time.Time
+	Deleted    sql.NullTime
+	ThreadID   string
+	ParentID   string
 }
 
-func (q *Queries) InsertMessage(ctx context.Context, db DBTX, arg InsertMessageParams) (*Message, error) {
+func (q *Queries) InsertMessage(ctx context.Context, db DBTX, arg InsertMessageParams) (*InsertMessageRow, error) {
 	row := db.QueryRowContext(ctx, insertMessage,
 		arg.ProviderID,
 		arg.ChannelID,
 		arg.AuthorID,
 		arg.Content,
 		arg.Timestamp,
+		arg.ThreadID,
+		arg.ParentID,
 	)
-	var i Message
+	var i InsertMessageRow
 	err := row.Scan(
 		&i.ID,
 		&i.ProviderID,
@@ -44,18 +93,32 @@ func (q *Queries) InsertMessage(ctx context.Context, db DBTX, arg InsertMessageP
 		&i.Content,
 		&i.Timestamp,
 		&i.Deleted,
+		&i.ThreadID,
+		&i.ParentID,
 	)
 	return &i, err
 }
 
 const latestBotMessageInChannel = `-- name: LatestBotMessageInChannel :one
-SELECT m.id, m.provider_id, m.channel_id, m.author_id, m.content, m.timestamp, m.deleted FROM message m join "user" u on m.author_id = u.id WHERE m.channel_id = $1 AND u.bot_id IS NOT NULL
+SELECT m.id, m.provider_id, m.channel_id, m.author_id, m.content, m.timestamp, m.deleted, m.thread_id, m.parent_id FROM message m join "user" u on m.author_id = u.id WHERE m.channel_id = $1 AND u.bot_id IS NOT NULL
 ORDER BY timestamp DESC LIMIT 1
 `
 
-func (q *Queries) LatestBotMessageInChannel(ctx context.Context, db DBTX, channelID uuid.UUID) (*Message, error) {
+type LatestBotMessageInChannelRow struct {
+	ID         uuid.UUID
+	ProviderID string
+	ChannelID  uuid.UUID
+	AuthorID   uuid.UUID
+	Content    string
+	Timestamp  time.Time
+	Deleted    sql.NullTime
+	ThreadID   string
+	ParentID   string
+}
+
+func (q *Queries) LatestBotMessageInChannel(ctx context.Context, db DBTX, channelID uuid.UUID) (*LatestBotMessageInChannelRow, error) {
 	row := db.QueryRowContext(ctx, latestBotMessageInChannel, channelID)
-	var i Message
+	var i LatestBotMessageInChannelRow
 	err := row.Scan(
 		&i.ID,
 		&i.ProviderID,
@@ -64,18 +127,32 @@ func (q *Queries) LatestBotMessageInChannel(ctx context.Context, db DBTX, channe
 		&i.Content,
 		&i.Timestamp,
 		&i.Deleted,
+		&i.ThreadID,
+		&i.ParentID,
 	)
 	return &i, err
 }
 
 const latestMessageInChannel = `-- name: LatestMessageInChannel :one
-SELECT m.id, m.provider_id, m.channel_id, m.author_id, m.content, m.timestamp, m.deleted FROM message m WHERE m.channel_id = $1
+SELECT m.id, m.provider_id, m.channel_id, m.author_id, m.content, m.timestamp, m.deleted, m.thread_id, m.parent_id FROM message m WHERE m.channel_id = $1 AND m.provider_id <> ''
 ORDER BY timestamp DESC LIMIT 1
 `
 
-func (q *Queries) LatestMessageInChannel(ctx context.Context, db DBTX, channelID uuid.UUID) (*Message, error) {
+type LatestMessageInChannelRow struct {
+	ID         uuid.UUID
+	ProviderID string
+	ChannelID  uuid.UUID
+	AuthorID   uuid.UUID
+	Content    string
+	Timestamp  time.Time
+	Deleted    sql.NullTime
+	ThreadID   string
+	ParentID   string
+}
+
+func (q *Queries) LatestMessageInChannel(ctx context.Context, db DBTX, channelID uuid.UUID) (*LatestMessageInChannelRow, error) {
 	row := db.QueryRowContext(ctx, latestMessageInChannel, channelID)
-	var i Message
+	var i LatestMessageInChannelRow
 	err := row.Scan(
 		&i.ID,
 		&i.ProviderID,
@@ -84,23 +161,37 @@ func (q *Queries) LatestMessageInChannel(ctx context.Context, db DBTX, channelID
 		&i.Content,
 		&i.Timestamp,
 		&i.Deleted,
+		&i.ThreadID,
+		&i.ParentID,
 	)
 	return &i, err
 }
 
 const listMessagesInChannel = `-- name: ListMessagesInChannel :many
-SELECT id, provider_id, channel_id, author_id, content, timestamp, deleted FROM message m WHERE m.channel_id = $1 and timestamp > NOW() - interval '3 days' order by timestamp desc LIMIT 25
+SELECT m.id, m.provider_id, m.channel_id, m.author_id, m.content, m.timestamp, m.deleted, m.thread_id, m.parent_id FROM message m WHERE m.channel_id = $1 and m.thread_id = '' and m.deleted IS NULL and timestamp > NOW() - interval '3 days' order by timestamp desc LIMIT 25
 `
 
-func (q *Queries) ListMessagesInChannel(ctx context.Context, db DBTX, channelID uuid.UUID) ([]*Message, error) {
+type ListMessagesInChannelRow struct {
+	ID         uuid.UUID
+	ProviderID string
+	ChannelID  uuid.UUID
+	AuthorID   uuid.UUID
+	Content    string
+	Timestamp  time.Time
+	Deleted    sql.NullTime
+	ThreadID   string
+	ParentID   string
+}
+
+func (q *Queries) ListMessagesInChannel(ctx context.Context, db DBTX, channelID uuid.UUID) ([]*ListMessagesInChannelRow, error) {
 	rows, err := db.QueryContext(ctx, listMessagesInChannel, channelID)
 	if err != nil {
 		return nil, err
 	}
 	defer rows.Close()
-	items := []*Message{}
+	items := []*ListMessagesInChannelRow{}
 	for rows.Next() {
-		var i Message
+		var i ListMessagesInChannelRow
 		if err := rows.Scan(
 			&i.ID,
 			&i.ProviderID,
@@ -109,6 +200,8 @@ func (q *Queries) ListMessagesInChannel(ctx context.Context, db DBTX, channelID
 			&i.Content,
 			&i.Timestamp,
 			&i.Deleted,
+			&i.ThreadID,
+			&i.ParentID,
 		); err != nil {
 			return nil, err
 		}
@@ -127,7 +220,7 @@ const listMessagesInChannelAfter = `-- name: ListMessagesInChannelAfter :many
 WITH targetTimestamp AS (
     SELECT timestamp FROM message m WHERE m.provider_id = $2
 )
-SELECT id, provider_id, channel_id, author_id, content, timestamp, deleted FROM message m WHERE m.channel_id = $1 and timestamp > (select timestamp from targetTimestamp) order by timestamp
+SELECT m.id, m.provider_id, m.channel_id, m.author_id, m.content, m.timestamp, m.deleted, m.thread_id, m.parent_id FROM message m WHERE m.channel_id = $1 and m.deleted IS NULL and timestamp > (select timestamp from targetTimestamp) order by timestamp
 `
 
 type ListMessagesInChannelAfterParams struct {
@@ -135,15 +228,81 @@ type ListMessagesInChannelAfterParams struct {
 	ProviderID string
 }
 
-func (q *Queries) ListMessagesInChannelAfter(ctx context.Context, db DBTX, arg ListMessagesInChannelAfterParams) ([]*Message, error) {
+type ListMessagesInChannelAfterRow struct {
+	ID         uuid.UUID
+	ProviderID string
+	ChannelID  uuid.UUID
+	AuthorID   uuid.UUID
+	Content    string
+	Timestamp  time.Time
+	Deleted    sql.NullTime
+	ThreadID   string
+	ParentID   string
+}
+
+func (q *Queries) ListMessagesInChannelAfter(ctx context.Context, db DBTX, arg ListMessagesInChannelAfterParams) ([]*ListMessagesInChannelAfterRow, error) {
 	rows, err := db.QueryContext(ctx, listMessagesInChannelAfter, arg.ChannelID, arg.ProviderID)
 	if err != nil {
 		return nil, err
 	}
 	defer rows.Close()
-	items := []*Message{}
+	items := []*ListMessagesInChannelAfterRow{}
+	for rows.Next() {
+		var i ListMessagesInChannelAfterRow
+		if err := rows.Scan(
+			&i.ID,
+			&i.ProviderID,
+			&i.ChannelID,
+			&i.AuthorID,
+			&i.Content,
+			&i.Timestamp,
+			&i.Deleted,
+			&i.ThreadID,
+			&i.ParentID,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, &i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listMessagesInThread = `-- name: ListMessagesInThread :many
+SELECT m.id, m.provider_id, m.channel_id, m.author_id, m.content, m.timestamp, m.deleted, m.thread_id, m.parent_id FROM message m WHERE m.channel_id = $1 and (m.thread_id = $2 or m.provider_id = $2) and m.deleted IS NULL order by timestamp desc LIMIT 25
+`
+
+type ListMessagesInThreadParams struct {
+	ChannelID uuid.UUID
+	ThreadID  string
+}
+
+type ListMessagesInThreadRow struct {
+	ID         uuid.UUID
+	ProviderID string
+	ChannelID  uuid.UUID
+	AuthorID   uuid.UUID
+	Content    string
+	Timestamp  time.Time
+	Deleted    sql.NullTime
+	ThreadID   string
+	ParentID   string
+}
+
+func (q *Queries) ListMessagesInThread(ctx context.Context, db DBTX, arg ListMessagesInThreadParams) ([]*ListMessagesInThreadRow, error) {
+	rows, err := db.QueryContext(ctx, listMessagesInThread, arg.ChannelID, arg.ThreadID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := []*ListMessagesInThreadRow{}
 	for rows.Next() {
-		var i Message
+		var i ListMessagesInThreadRow
 		if err := rows.Scan(
 			&i.ID,
 			&i.ProviderID,
@@ -152,6 +311,8 @@ func (q *Queries) ListMessagesInChannelAfter(ctx context.Context, db DBTX, arg L
 			&i.Content,
 			&i.Timestamp,
 			&i.Deleted,
+			&i.ThreadID,
+			&i.ParentID,
 		); err != nil {
 			return nil, err
 		}
@@ -165,3 +326,19 @@ func (q *Queries) ListMessagesInChannelAfter(ctx context.Context, db DBTX, arg L
 	}
 	return items, nil
 }
+
+const updateMessageContent = `-- name: UpdateMessageContent :exec
+UPDATE message SET content = $1
+WHERE channel_id = $2 AND provider_id = $3 AND deleted IS NULL
+`
+
+type UpdateMessageContentParams struct {
+	Content    string
+	ChannelID  uuid.UUID
+	ProviderID string
+}
+
+func (q *Queries) UpdateMessageContent(ctx context.Context, db DBTX, arg UpdateMessageContentParams) error {
+	_, err := db.ExecContext(ctx, updateMessageContent, arg.Content, arg.ChannelID, arg.ProviderID)
+	return err
+}
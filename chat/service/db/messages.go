@@ -0,0 +1,53 @@
+package db
+
+import (
+	"database/sql"
+	"time"
+
+	"encore.dev/types/uuid"
+)
+
+// messageRow is a row of the message queries. They select all columns of message but search_vector, which only the
+// search queries need, so they return their own row type instead of Message.
+type messageRow interface {
+	InsertMessageRow | LatestMessageInChannelRow | LatestBotMessageInChannelRow | ListMessagesInChannelRow |
+		ListMessagesInThreadRow | ListMessagesInChannelAfterRow | ListChannelTranscriptRow
+}
+
+// messageColumns are the columns of the message rows
+type messageColumns struct {
+	ID         uuid.UUID
+	ProviderID string
+	ChannelID  uuid.UUID
+	AuthorID   uuid.UUID
+	Content    string
+	Timestamp  time.Time
+	Deleted    sql.NullTime
+	ThreadID   string
+	ParentID   string
+}
+
+// ToMessage converts a row of a message query to a Message
+func ToMessage[T messageRow](row *T) *Message {
+	c := messageColumns(*row)
+	return &Message{
+		ID:         c.ID,
+		ProviderID: c.ProviderID,
+		ChannelID:  c.ChannelID,
+		AuthorID:   c.AuthorID,
+		Content:    c.Content,
+		Timestamp:  c.Timestamp,
+		Deleted:    c.Deleted,
+		ThreadID:   c.ThreadID,
+		ParentID:   c.ParentID,
+	}
+}
+
+// ToMessages converts the rows of a message query to Messages
+func ToMessages[T messageRow](rows []*T) []*Message {
+	msgs := make([]*Message, len(rows))
+	for i, row := range rows {
+		msgs[i] = ToMessage(row)
+	}
+	return msgs
+}
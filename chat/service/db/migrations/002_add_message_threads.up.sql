@@ -0,0 +1,6 @@
+-- thread_id is the provider ID of the thread a message was posted in, and parent_id the provider ID
+-- of the message it replies to. Both are empty for top-level messages.
+ALTER TABLE message ADD COLUMN thread_id TEXT NOT NULL DEFAULT '';
+ALTER TABLE message ADD COLUMN parent_id TEXT NOT NULL DEFAULT '';
+
+CREATE INDEX message_thread_idx ON message (channel_id, thread_id);
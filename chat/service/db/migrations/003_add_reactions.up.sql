@@ -0,0 +1,8 @@
+CREATE TABLE IF NOT EXISTS reaction (
+    id uuid PRIMARY KEY,
+    message_id uuid NOT NULL,
+    user_id uuid NOT NULL,
+    emoji TEXT NOT NULL,
+    timestamp TIMESTAMP NOT NULL,
+    UNIQUE (message_id, user_id, emoji)
+);
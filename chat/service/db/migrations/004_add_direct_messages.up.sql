@@ -0,0 +1,2 @@
+-- dm_user is the provider ID of the user in a direct message channel, it's empty for all other channels
+ALTER TABLE channel ADD COLUMN dm_user TEXT NOT NULL DEFAULT '';
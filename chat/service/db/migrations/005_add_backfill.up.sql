@@ -0,0 +1,9 @@
+-- backfill tracks the progress of loading the message history of a channel from its provider
+CREATE TABLE IF NOT EXISTS backfill (
+    channel_id uuid PRIMARY KEY,
+    -- next_cursor is the provider ID of the oldest message loaded so far, the backfill resumes before it
+    next_cursor TEXT NOT NULL DEFAULT '',
+    messages INT NOT NULL DEFAULT 0,
+    completed BOOLEAN NOT NULL DEFAULT FALSE,
+    updated TIMESTAMP NOT NULL DEFAULT NOW()
+);
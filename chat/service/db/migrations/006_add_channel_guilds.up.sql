@@ -0,0 +1,2 @@
+-- guild_id is the provider ID of the server a channel belongs to, it's empty for providers without servers
+ALTER TABLE channel ADD COLUMN guild_id TEXT NOT NULL DEFAULT '';
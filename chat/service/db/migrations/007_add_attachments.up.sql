@@ -0,0 +1,17 @@
+-- attachment stores the files, links and code snippets attached to messages
+CREATE TABLE IF NOT EXISTS attachment (
+    id uuid PRIMARY KEY,
+    message_id uuid NOT NULL,
+    -- position is the index of the attachment in the message
+    position INT NOT NULL,
+    type TEXT NOT NULL,
+    name TEXT NOT NULL,
+    url TEXT NOT NULL,
+    mime_type TEXT NOT NULL,
+    size BIGINT NOT NULL,
+    description TEXT NOT NULL,
+    -- text is the extracted text of text files and the content of code snippets
+    text TEXT NOT NULL
+);
+
+CREATE INDEX attachment_message_idx ON attachment (message_id);
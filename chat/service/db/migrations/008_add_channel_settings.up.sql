@@ -0,0 +1,18 @@
+-- channel_settings controls how often bots reply to messages in a channel. Channels without settings use the
+-- defaults of the columns.
+CREATE TABLE IF NOT EXISTS channel_settings (
+    channel_id uuid PRIMARY KEY,
+    -- reply_probability is the chance that bots reply to a message, from 0 to 1
+    reply_probability DOUBLE PRECISION NOT NULL DEFAULT 1,
+    -- cooldown_seconds is the minimum time between the latest bot message and the next reply
+    cooldown_seconds INT NOT NULL DEFAULT 0,
+    -- max_messages_per_hour limits the number of bot messages per hour, 0 is unlimited
+    max_messages_per_hour INT NOT NULL DEFAULT 0,
+    -- quiet_start and quiet_end are times of day (HH:MM) in timezone during which bots don't reply
+    quiet_start TEXT NOT NULL DEFAULT '',
+    quiet_end TEXT NOT NULL DEFAULT '',
+    timezone TEXT NOT NULL DEFAULT 'UTC',
+    -- mentions_only makes bots only reply to messages which mention them
+    mentions_only BOOLEAN NOT NULL DEFAULT FALSE,
+    updated TIMESTAMP NOT NULL DEFAULT NOW()
+);
@@ -0,0 +1,15 @@
+-- autopilot tracks bot-only conversations which run for a number of rounds or until a token budget is spent
+CREATE TABLE IF NOT EXISTS autopilot (
+    channel_id uuid PRIMARY KEY,
+    topic TEXT NOT NULL DEFAULT '',
+    max_rounds INT NOT NULL,
+    rounds INT NOT NULL DEFAULT 0,
+    -- token_budget is the maximum number of tokens the bots may write, 0 is unlimited
+    token_budget INT NOT NULL DEFAULT 0,
+    tokens INT NOT NULL DEFAULT 0,
+    pause_seconds INT NOT NULL DEFAULT 60,
+    started TIMESTAMP NOT NULL DEFAULT NOW(),
+    round_started TIMESTAMP NOT NULL DEFAULT NOW(),
+    stopped TIMESTAMP DEFAULT NULL,
+    stop_reason TEXT NOT NULL DEFAULT ''
+);
@@ -0,0 +1,22 @@
+-- schedule instructs the bots in a channel to start a conversation at the times of a cron expression
+CREATE TABLE IF NOT EXISTS schedule (
+    id uuid PRIMARY KEY,
+    channel_id uuid NOT NULL,
+    name TEXT NOT NULL,
+    cron TEXT NOT NULL,
+    timezone TEXT NOT NULL DEFAULT 'UTC',
+    -- jitter_seconds delays each run by a random duration up to this many seconds
+    jitter_seconds INT NOT NULL DEFAULT 0,
+    -- window_start and window_end are times of day (HH:MM) in timezone outside of which runs are skipped
+    window_start TEXT NOT NULL DEFAULT '',
+    window_end TEXT NOT NULL DEFAULT '',
+    -- instruction is a text/template executed with the channel name and the time of the run
+    instruction TEXT NOT NULL,
+    disabled BOOLEAN NOT NULL DEFAULT FALSE,
+    next_run TIMESTAMP NOT NULL,
+    last_run TIMESTAMP DEFAULT NULL,
+    created TIMESTAMP NOT NULL DEFAULT NOW(),
+    deleted TIMESTAMP DEFAULT NULL
+);
+
+CREATE INDEX IF NOT EXISTS schedule_next_run_idx ON schedule (next_run) WHERE deleted IS NULL;
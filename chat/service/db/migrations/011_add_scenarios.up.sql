@@ -0,0 +1,20 @@
+-- scenario is a storyline of beats the bots in a channel follow, see ScenarioDefinition for the definition
+CREATE TABLE IF NOT EXISTS scenario (
+    id uuid PRIMARY KEY,
+    name TEXT NOT NULL,
+    definition JSONB NOT NULL,
+    created TIMESTAMP NOT NULL DEFAULT NOW(),
+    deleted TIMESTAMP DEFAULT NULL
+);
+
+-- scenario_run tracks the progress of a scenario in a channel
+CREATE TABLE IF NOT EXISTS scenario_run (
+    channel_id uuid PRIMARY KEY,
+    scenario_id uuid NOT NULL,
+    -- beat is the index of the latest beat that was played
+    beat INT NOT NULL DEFAULT 0,
+    status TEXT NOT NULL,
+    -- next_at is when the next beat is played, NULL while waiting for a human without a timeout
+    next_at TIMESTAMP DEFAULT NULL,
+    updated TIMESTAMP NOT NULL DEFAULT NOW()
+);
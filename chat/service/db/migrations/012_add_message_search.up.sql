@@ -0,0 +1,3 @@
+-- The full-text search index is built on an expression rather than a stored tsvector column, so queries
+-- selecting all columns of messages are unaffected. Search queries must use the same expression to hit it.
+CREATE INDEX message_search_idx ON message USING GIN (to_tsvector('english', content));
@@ -0,0 +1,2 @@
+-- retention_days is how many days messages in a channel are kept, 0 uses the RetentionDays config of the provider
+ALTER TABLE channel_settings ADD COLUMN retention_days INT NOT NULL DEFAULT 0;
@@ -0,0 +1,6 @@
+-- search_vector replaces the expression index of 012_add_message_search, so search queries can't miss the index
+-- by using a slightly different expression
+ALTER TABLE message ADD COLUMN search_vector tsvector GENERATED ALWAYS AS (to_tsvector('english', content)) STORED;
+
+DROP INDEX IF EXISTS message_search_idx;
+CREATE INDEX message_search_vector_idx ON message USING GIN (search_vector);
@@ -0,0 +1,12 @@
+-- slack_id_repair lists the Slack channels with messages stored before the ts became their provider ID. They're
+-- repaired once by the next backfill of the channel, which removes them from the list.
+CREATE TABLE slack_id_repair (
+    channel_id UUID PRIMARY KEY REFERENCES channel (id)
+);
+
+INSERT INTO slack_id_repair (channel_id)
+SELECT DISTINCT m.channel_id FROM message m
+JOIN channel c ON c.id = m.channel_id
+JOIN "user" u ON u.id = m.author_id
+WHERE c.provider = 'slack' AND u.provider = 'slack' AND u.provider_id NOT LIKE 'import:%'
+  AND (m.provider_id = '' OR m.provider_id ~* '^[0-9a-f]{8}-[0-9a-f]{4}-[0-9a-f]{4}-[0-9a-f]{4}-[0-9a-f]{12}$');
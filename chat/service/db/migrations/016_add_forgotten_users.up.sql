@@ -0,0 +1,9 @@
+-- forgotten_user records the provider users who asked to be forgotten, so their messages aren't loaded again when
+-- the history of a channel is backfilled. user_id is the anonymized user if their messages were kept.
+CREATE TABLE forgotten_user (
+    provider provider NOT NULL,
+    provider_id TEXT NOT NULL,
+    user_id UUID REFERENCES "user" (id) ON DELETE SET NULL,
+    forgotten TIMESTAMP NOT NULL,
+    PRIMARY KEY (provider, provider_id)
+);
@@ -0,0 +1,3 @@
+-- channel_closed marks the schedules which were disabled because their channel was archived or deleted, so they're
+-- enabled again when the channel is restored
+ALTER TABLE schedule ADD COLUMN channel_closed BOOLEAN NOT NULL DEFAULT FALSE;
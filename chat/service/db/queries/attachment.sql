@@ -0,0 +1,11 @@
+-- name: InsertAttachment :exec
+INSERT INTO attachment (id, message_id, position, type, name, url, mime_type, size, description, text)
+SELECT gen_random_uuid (), m.id, @position, @type, @name, @url, @mime_type, @size, @description, @text
+FROM message m WHERE m.channel_id = @channel_id AND m.provider_id = @message_id;
+
+-- name: DeleteLinkAttachments :exec
+DELETE FROM attachment a USING message m
+WHERE a.message_id = m.id AND m.channel_id = @channel_id AND m.provider_id = @message_id AND a.type = 'link';
+
+-- name: ListAttachmentsForMessages :many
+SELECT * FROM attachment WHERE message_id = ANY(@message_ids::uuid[]) ORDER BY message_id, position;
@@ -0,0 +1,21 @@
+-- name: UpsertAutopilot :one
+INSERT INTO autopilot (channel_id, topic, max_rounds, token_budget, pause_seconds, rounds, tokens, started, round_started, stopped, stop_reason)
+VALUES ($1, $2, $3, $4, $5, 0, 0, NOW(), NOW(), NULL, '')
+ON CONFLICT (channel_id) DO UPDATE SET topic = $2, max_rounds = $3, token_budget = $4, pause_seconds = $5,
+    rounds = 0, tokens = 0, started = NOW(), round_started = NOW(), stopped = NULL, stop_reason = ''
+RETURNING *;
+
+-- name: GetAutopilot :one
+SELECT * FROM autopilot WHERE channel_id = $1;
+
+-- name: ListActiveAutopilots :many
+SELECT * FROM autopilot WHERE stopped IS NULL;
+
+-- name: StartAutopilotRound :exec
+UPDATE autopilot SET rounds = rounds + 1, round_started = NOW() WHERE channel_id = $1 AND stopped IS NULL;
+
+-- name: AddAutopilotTokens :exec
+UPDATE autopilot SET tokens = tokens + @tokens WHERE channel_id = @channel_id AND stopped IS NULL;
+
+-- name: StopAutopilot :execrows
+UPDATE autopilot SET stopped = NOW(), stop_reason = @stop_reason WHERE channel_id = @channel_id AND stopped IS NULL;
@@ -0,0 +1,36 @@
+-- name: GetBackfill :one
+SELECT * FROM backfill WHERE channel_id = $1;
+
+-- name: UpsertBackfill :exec
+INSERT INTO backfill (channel_id, next_cursor, messages, completed, updated)
+VALUES ($1, $2, $3, $4, NOW())
+ON CONFLICT (channel_id) DO UPDATE SET next_cursor = $2, messages = $3, completed = $4, updated = NOW();
+
+-- Slack messages stored before the ts became their provider ID have the client_msg_id, a UUID, or an empty ID if
+-- a bot or webhook posted them. Admin instructions and imported messages have an empty ID too, they're left alone.
+-- The channels which had legacy messages when the ts became the ID are listed in slack_id_repair, and are repaired
+-- once by their next backfill.
+
+-- name: SlackIDRepairPending :one
+SELECT EXISTS(SELECT 1 FROM slack_id_repair WHERE channel_id = $1);
+
+-- name: CompleteSlackIDRepair :exec
+DELETE FROM slack_id_repair WHERE channel_id = $1;
+
+-- name: OldestLegacySlackMessage :one
+SELECT timestamp FROM message
+WHERE channel_id = $1 AND (provider_id = '' OR provider_id ~* '^[0-9a-f]{8}-[0-9a-f]{4}-[0-9a-f]{4}-[0-9a-f]{4}-[0-9a-f]{12}$')
+  AND author_id IN (SELECT id FROM "user" WHERE provider = 'slack' AND provider_id NOT LIKE 'import:%')
+ORDER BY timestamp LIMIT 1;
+
+-- name: SetLegacySlackMessageID :execrows
+UPDATE message SET provider_id = $1
+WHERE channel_id = $2 AND timestamp = $3
+  AND (provider_id = '' OR provider_id ~* '^[0-9a-f]{8}-[0-9a-f]{4}-[0-9a-f]{4}-[0-9a-f]{4}-[0-9a-f]{12}$')
+  AND author_id IN (SELECT id FROM "user" WHERE provider = 'slack' AND provider_id NOT LIKE 'import:%');
+
+-- name: MarkLegacySlackMessages :execrows
+UPDATE message SET provider_id = 'legacy:' || id
+WHERE channel_id = $1
+  AND (provider_id = '' OR provider_id ~* '^[0-9a-f]{8}-[0-9a-f]{4}-[0-9a-f]{4}-[0-9a-f]{4}-[0-9a-f]{12}$')
+  AND author_id IN (SELECT id FROM "user" WHERE provider = 'slack' AND provider_id NOT LIKE 'import:%');
@@ -11,16 +11,22 @@ SELECT * FROM channel WHERE id = @id AND deleted IS NULL;
 SELECT * FROM channel WHERE provider_id = @provider_id AND provider = @provider AND deleted IS NULL;
 
 -- name: UpsertChannel :one
-INSERT INTO channel (id, provider_id, provider, name)
-SELECT coalesce(id, new_id), @provider_id, @provider, @name
+INSERT INTO channel (id, provider_id, provider, name, dm_user, guild_id)
+SELECT coalesce(id, new_id), @provider_id, @provider, @name, @dm_user, @guild_id
 FROM (VALUES(gen_random_uuid())) AS data(new_id) LEFT JOIN channel c
 ON c.provider = @provider AND c.provider_id = @provider_id
-ON CONFLICT(provider_id, provider) DO UPDATE SET name = @name
+ON CONFLICT(provider_id, provider) DO UPDATE SET name = @name, dm_user = @dm_user, guild_id = @guild_id
 RETURNING *;
 
 -- name: GetChannelByProviderID :one
 SELECT * FROM channel WHERE provider_id = @provider_id AND provider = @provider AND deleted IS NULL;
 
+-- name: RestoreChannel :exec
+UPDATE channel SET deleted = NULL WHERE provider_id = @provider_id AND provider = @provider;
+
+-- name: DeleteGuildChannels :exec
+UPDATE channel SET deleted = NOW() WHERE provider = @provider AND guild_id = @guild_id AND deleted IS NULL;
+
 -- name: ListChannelsWithBots :many
 WITH channelIds AS (
     SELECT distinct channel as id FROM bot_channel WHERE deleted IS NULL
@@ -37,4 +43,13 @@ UPDATE bot_channel SET deleted = NOW() WHERE bot = $1 AND channel = $2 RETURNING
 SELECT bot FROM bot_channel WHERE bot = $1 AND channel = $2 AND deleted IS NULL;
 
 -- name: ListBotsInChannel :many
-SELECT bot FROM bot_channel WHERE channel = $1 AND deleted IS NULL;
\ No newline at end of file
+SELECT bot FROM bot_channel WHERE channel = $1 AND deleted IS NULL;
+
+-- name: ListGuildChannels :many
+SELECT * FROM channel WHERE provider = @provider AND guild_id = @guild_id AND deleted IS NULL;
+
+-- name: DeleteChannel :exec
+UPDATE channel SET deleted = NOW() WHERE id = $1 AND deleted IS NULL;
+
+-- name: RemoveChannelBots :many
+UPDATE bot_channel SET deleted = NOW() WHERE channel = $1 AND deleted IS NULL RETURNING bot;
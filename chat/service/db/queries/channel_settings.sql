@@ -0,0 +1,10 @@
+-- name: GetChannelSettings :one
+SELECT * FROM channel_settings WHERE channel_id = $1;
+
+-- name: UpsertChannelSettings :one
+INSERT INTO channel_settings (channel_id, reply_probability, cooldown_seconds, max_messages_per_hour, quiet_start, quiet_end, timezone, mentions_only, retention_days, updated)
+VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, NOW())
+ON CONFLICT (channel_id) DO UPDATE SET reply_probability = $2, cooldown_seconds = $3, max_messages_per_hour = $4,
+    quiet_start = $5, quiet_end = $6, timezone = $7, mentions_only = $8,
+    retention_days = $9, updated = NOW()
+RETURNING *;
@@ -1,22 +1,40 @@
+-- The queries on messages list their columns, search_vector is only selected by the search queries. It's often
+-- larger than the content.
+
 -- name: InsertMessage :one
-INSERT INTO message (id, provider_id, channel_id, author_id, content, timestamp)
-VALUES (gen_random_uuid (), $1, $2, $3, $4, $5)
+INSERT INTO message (id, provider_id, channel_id, author_id, content, timestamp, thread_id, parent_id)
+VALUES (gen_random_uuid (), $1, $2, $3, $4, $5, $6, $7)
 ON CONFLICT (channel_id, author_id, content, timestamp) DO NOTHING
-RETURNING *;
+RETURNING id, provider_id, channel_id, author_id, content, timestamp, deleted, thread_id, parent_id;
 
 -- name: LatestMessageInChannel :one
-SELECT m.* FROM message m WHERE m.channel_id = $1
+SELECT m.id, m.provider_id, m.channel_id, m.author_id, m.content, m.timestamp, m.deleted, m.thread_id, m.parent_id FROM message m WHERE m.channel_id = $1 AND m.provider_id <> ''
 ORDER BY timestamp DESC LIMIT 1;
 
 -- name: LatestBotMessageInChannel :one
-SELECT m.* FROM message m join "user" u on m.author_id = u.id WHERE m.channel_id = $1 AND u.bot_id IS NOT NULL
+SELECT m.id, m.provider_id, m.channel_id, m.author_id, m.content, m.timestamp, m.deleted, m.thread_id, m.parent_id FROM message m join "user" u on m.author_id = u.id WHERE m.channel_id = $1 AND u.bot_id IS NOT NULL
 ORDER BY timestamp DESC LIMIT 1;
 
+-- name: CountBotMessagesSince :one
+SELECT count(*) FROM message m join "user" u on m.author_id = u.id
+WHERE m.channel_id = @channel_id AND u.bot_id IS NOT NULL AND m.deleted IS NULL AND m.timestamp > @since;
+
 -- name: ListMessagesInChannel :many
-SELECT * FROM message m WHERE m.channel_id = $1 and timestamp > NOW() - interval '3 days' order by timestamp desc LIMIT 25;
+SELECT m.id, m.provider_id, m.channel_id, m.author_id, m.content, m.timestamp, m.deleted, m.thread_id, m.parent_id FROM message m WHERE m.channel_id = $1 and m.thread_id = '' and m.deleted IS NULL and timestamp > NOW() - interval '3 days' order by timestamp desc LIMIT 25;
+
+-- name: ListMessagesInThread :many
+SELECT m.id, m.provider_id, m.channel_id, m.author_id, m.content, m.timestamp, m.deleted, m.thread_id, m.parent_id FROM message m WHERE m.channel_id = @channel_id and (m.thread_id = @thread_id or m.provider_id = @thread_id) and m.deleted IS NULL order by timestamp desc LIMIT 25;
 
 -- name: ListMessagesInChannelAfter :many
 WITH targetTimestamp AS (
     SELECT timestamp FROM message m WHERE m.provider_id = $2
 )
-SELECT * FROM message m WHERE m.channel_id = $1 and timestamp > (select timestamp from targetTimestamp) order by timestamp;
\ No newline at end of file
+SELECT m.id, m.provider_id, m.channel_id, m.author_id, m.content, m.timestamp, m.deleted, m.thread_id, m.parent_id FROM message m WHERE m.channel_id = $1 and m.deleted IS NULL and timestamp > (select timestamp from targetTimestamp) order by timestamp;
+
+-- name: UpdateMessageContent :exec
+UPDATE message SET content = @content
+WHERE channel_id = @channel_id AND provider_id = @provider_id AND deleted IS NULL;
+
+-- name: DeleteMessage :exec
+UPDATE message SET deleted = NOW()
+WHERE channel_id = @channel_id AND provider_id = @provider_id AND deleted IS NULL;
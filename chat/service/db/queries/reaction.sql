@@ -0,0 +1,15 @@
+-- name: InsertReaction :exec
+INSERT INTO reaction (id, message_id, user_id, emoji, timestamp)
+SELECT gen_random_uuid (), m.id, @user_id, @emoji, @timestamp
+FROM message m WHERE m.channel_id = @channel_id AND m.provider_id = @message_id
+ON CONFLICT (message_id, user_id, emoji) DO NOTHING;
+
+-- name: DeleteReaction :exec
+DELETE FROM reaction r USING message m
+WHERE r.message_id = m.id AND m.channel_id = @channel_id AND m.provider_id = @message_id
+  AND r.user_id = @user_id AND r.emoji = @emoji;
+
+-- name: ListReactionsInChannel :many
+SELECT r.* FROM reaction r JOIN message m ON r.message_id = m.id
+WHERE m.channel_id = $1 AND m.deleted IS NULL AND m.timestamp > NOW() - interval '3 days'
+ORDER BY r.timestamp;
@@ -0,0 +1,64 @@
+-- name: ListChannelRetention :many
+SELECT c.id, c.provider, COALESCE(s.retention_days, 0)::int AS retention_days
+FROM channel c LEFT JOIN channel_settings s ON s.channel_id = c.id;
+
+-- name: PurgeMessagesBefore :one
+WITH purged AS (
+    DELETE FROM message WHERE channel_id = @channel_id AND timestamp < @before RETURNING id
+), attachments AS (
+    DELETE FROM attachment WHERE message_id IN (SELECT id FROM purged)
+), reactions AS (
+    DELETE FROM reaction WHERE message_id IN (SELECT id FROM purged)
+)
+SELECT count(*) FROM purged;
+
+-- name: PurgeDeletedMessages :one
+WITH purged AS (
+    DELETE FROM message WHERE deleted < @before RETURNING id
+), attachments AS (
+    DELETE FROM attachment WHERE message_id IN (SELECT id FROM purged)
+), reactions AS (
+    DELETE FROM reaction WHERE message_id IN (SELECT id FROM purged)
+)
+SELECT count(*) FROM purged;
+
+-- name: PurgeMessagesByAuthor :one
+WITH purged AS (
+    DELETE FROM message WHERE author_id = @author_id RETURNING id
+), attachments AS (
+    DELETE FROM attachment WHERE message_id IN (SELECT id FROM purged)
+), reactions AS (
+    DELETE FROM reaction WHERE message_id IN (SELECT id FROM purged)
+)
+SELECT count(*) FROM purged;
+
+-- name: PurgeDeletedBotChannels :execrows
+DELETE FROM bot_channel WHERE deleted < @before;
+
+-- name: PurgeOrphanedUsers :execrows
+DELETE FROM "user" u
+WHERE u.provider <> 'admin'
+  AND NOT EXISTS (SELECT 1 FROM message m WHERE m.author_id = u.id)
+  AND NOT EXISTS (SELECT 1 FROM reaction r WHERE r.user_id = u.id);
+
+-- name: ListUsersByProviderID :many
+SELECT * FROM "user" WHERE provider = $1 AND provider_id = $2;
+
+-- name: CountMessagesByAuthor :one
+SELECT count(*) FROM message WHERE author_id = $1;
+
+-- name: DeleteReactionsByUser :exec
+DELETE FROM reaction WHERE user_id = $1;
+
+-- name: DeleteUser :exec
+DELETE FROM "user" WHERE id = $1;
+
+-- name: AnonymizeUser :exec
+UPDATE "user" SET provider_id = @provider_id, name = @name, profile = '' WHERE id = @id;
+
+-- name: RecordForgottenUser :exec
+INSERT INTO forgotten_user (provider, provider_id, user_id, forgotten) VALUES (@provider, @provider_id, @user_id, NOW())
+ON CONFLICT (provider, provider_id) DO UPDATE SET user_id = @user_id, forgotten = NOW();
+
+-- name: ListForgottenUsers :many
+SELECT * FROM forgotten_user WHERE provider = $1;
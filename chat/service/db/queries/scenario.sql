@@ -0,0 +1,31 @@
+-- name: InsertScenario :one
+INSERT INTO scenario (id, name, definition) VALUES (gen_random_uuid(), $1, $2) RETURNING *;
+
+-- name: GetScenario :one
+SELECT * FROM scenario WHERE id = $1 AND deleted IS NULL;
+
+-- GetScenarioDefinition includes deleted scenarios, so channels which are running them can play them to the end
+
+-- name: GetScenarioDefinition :one
+SELECT definition FROM scenario WHERE id = $1;
+
+-- name: ListScenarios :many
+SELECT * FROM scenario WHERE deleted IS NULL ORDER BY created;
+
+-- name: DeleteScenario :execrows
+UPDATE scenario SET deleted = NOW() WHERE id = $1 AND deleted IS NULL;
+
+-- name: UpsertScenarioRun :one
+INSERT INTO scenario_run (channel_id, scenario_id, beat, status, next_at, updated)
+VALUES ($1, $2, $3, $4, $5, NOW())
+ON CONFLICT (channel_id) DO UPDATE SET scenario_id = $2, beat = $3, status = $4, next_at = $5, updated = NOW()
+RETURNING *;
+
+-- name: GetScenarioRun :one
+SELECT * FROM scenario_run WHERE channel_id = $1;
+
+-- name: ListDueScenarioRuns :many
+SELECT * FROM scenario_run WHERE status IN ('running', 'waiting') AND next_at <= NOW();
+
+-- name: UpdateScenarioRun :exec
+UPDATE scenario_run SET beat = $1, status = $2, next_at = $3, updated = NOW() WHERE channel_id = $4;
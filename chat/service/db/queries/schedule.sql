@@ -0,0 +1,38 @@
+-- name: InsertSchedule :one
+INSERT INTO schedule (id, channel_id, name, cron, timezone, jitter_seconds, window_start, window_end, instruction, disabled, next_run)
+VALUES (gen_random_uuid(), $1, $2, $3, $4, $5, $6, $7, $8, $9, $10)
+RETURNING *;
+
+-- name: UpdateSchedule :one
+UPDATE schedule SET name = $1, cron = $2, timezone = $3, jitter_seconds = $4, window_start = $5, window_end = $6,
+    instruction = $7, disabled = $8, next_run = $9, channel_closed = FALSE
+WHERE id = $10 AND deleted IS NULL
+RETURNING *;
+
+-- name: GetSchedule :one
+SELECT * FROM schedule WHERE id = $1 AND deleted IS NULL;
+
+-- name: ListSchedulesInChannel :many
+SELECT * FROM schedule WHERE channel_id = $1 AND deleted IS NULL ORDER BY created;
+
+-- name: ListDueSchedules :many
+SELECT * FROM schedule WHERE deleted IS NULL AND NOT disabled AND next_run <= NOW();
+
+-- name: ListScheduledChannels :many
+SELECT DISTINCT channel_id FROM schedule WHERE deleted IS NULL AND NOT disabled;
+
+-- name: SetScheduleRun :exec
+UPDATE schedule SET next_run = $1, last_run = NOW() WHERE id = $2;
+
+-- name: SetScheduleNextRun :exec
+UPDATE schedule SET next_run = $1 WHERE id = $2;
+
+-- name: DeleteSchedule :execrows
+UPDATE schedule SET deleted = NOW() WHERE id = $1 AND deleted IS NULL;
+
+-- name: DisableChannelSchedules :exec
+UPDATE schedule SET disabled = TRUE, channel_closed = TRUE WHERE channel_id = $1 AND deleted IS NULL AND NOT disabled;
+
+-- name: EnableChannelSchedules :many
+UPDATE schedule SET disabled = FALSE, channel_closed = FALSE WHERE channel_id = $1 AND channel_closed AND deleted IS NULL
+RETURNING *;
@@ -0,0 +1,15 @@
+-- name: SearchMessages :many
+SELECT m.id, m.channel_id, m.author_id, m.timestamp, c.name AS channel_name, u.name AS author_name, u.bot_id,
+       ts_headline('english', m.content, websearch_to_tsquery('english', @query),
+                   'StartSel=**, StopSel=**, MaxFragments=2')::text AS snippet
+FROM message m
+JOIN channel c ON m.channel_id = c.id
+JOIN "user" u ON m.author_id = u.id
+WHERE m.search_vector @@ websearch_to_tsquery('english', @query)
+  AND m.deleted IS NULL
+  AND (sqlc.narg(channel_id)::uuid IS NULL OR m.channel_id = sqlc.narg(channel_id))
+  AND (sqlc.narg(author)::text IS NULL OR lower(u.name) = lower(sqlc.narg(author)))
+  AND (sqlc.narg(from_time)::timestamp IS NULL OR m.timestamp >= sqlc.narg(from_time))
+  AND (sqlc.narg(to_time)::timestamp IS NULL OR m.timestamp < sqlc.narg(to_time))
+ORDER BY ts_rank(m.search_vector, websearch_to_tsquery('english', @query)) DESC, m.timestamp DESC
+LIMIT @max_results;
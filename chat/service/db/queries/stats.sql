@@ -0,0 +1,59 @@
+-- name: ChannelMessagesPerDay :many
+SELECT date_trunc('day', m.timestamp)::timestamp AS day,
+       count(*) FILTER (WHERE u.bot_id IS NULL) AS human_messages,
+       count(*) FILTER (WHERE u.bot_id IS NOT NULL) AS bot_messages,
+       count(DISTINCT m.author_id) FILTER (WHERE u.bot_id IS NULL) AS active_humans
+FROM message m JOIN "user" u ON m.author_id = u.id
+WHERE m.channel_id = @channel_id AND m.timestamp >= @since AND m.deleted IS NULL AND u.provider <> 'admin'
+GROUP BY day ORDER BY day;
+
+-- name: CountActiveHumansInChannel :one
+SELECT count(DISTINCT m.author_id) FROM message m JOIN "user" u ON m.author_id = u.id
+WHERE m.channel_id = @channel_id AND m.timestamp >= @since AND m.deleted IS NULL AND u.bot_id IS NULL
+  AND u.provider <> 'admin';
+
+-- name: ChannelReplyStats :one
+WITH human AS (
+    SELECT m.channel_id, m.thread_id, m.timestamp,
+           lead(m.timestamp) OVER (PARTITION BY m.thread_id ORDER BY m.timestamp) AS next_human
+    FROM message m JOIN "user" u ON m.author_id = u.id
+    WHERE m.channel_id = @channel_id AND m.timestamp >= @since AND m.deleted IS NULL AND u.bot_id IS NULL
+      AND u.provider <> 'admin'
+), replies AS (
+    SELECT h.timestamp, (
+        SELECT min(b.timestamp) FROM message b JOIN "user" bu ON b.author_id = bu.id
+        WHERE b.channel_id = h.channel_id AND b.thread_id = h.thread_id AND b.deleted IS NULL
+          AND bu.bot_id IS NOT NULL AND b.timestamp > h.timestamp
+          AND (h.next_human IS NULL OR b.timestamp < h.next_human)
+    ) AS first_reply
+    FROM human h
+)
+SELECT count(*) AS human_messages, count(first_reply) AS replied,
+       COALESCE(avg(EXTRACT(EPOCH FROM first_reply - timestamp)), 0)::float8 AS avg_response_seconds
+FROM replies;
+
+-- name: BotMessagesPerDay :many
+SELECT date_trunc('day', m.timestamp)::timestamp AS day, count(*) AS messages
+FROM message m JOIN "user" u ON m.author_id = u.id
+WHERE u.bot_id = @bot_id::uuid AND m.timestamp >= @since AND m.deleted IS NULL
+GROUP BY day ORDER BY day;
+
+-- name: BotReplyStats :one
+WITH human AS (
+    SELECT m.channel_id, m.thread_id, m.timestamp,
+           lead(m.timestamp) OVER (PARTITION BY m.channel_id, m.thread_id ORDER BY m.timestamp) AS next_human
+    FROM message m JOIN "user" u ON m.author_id = u.id
+    JOIN bot_channel bc ON bc.channel = m.channel_id AND bc.bot = @bot_id::uuid AND bc.deleted IS NULL
+    WHERE m.timestamp >= @since AND m.deleted IS NULL AND u.bot_id IS NULL AND u.provider <> 'admin'
+), replies AS (
+    SELECT h.timestamp, (
+        SELECT min(b.timestamp) FROM message b JOIN "user" bu ON b.author_id = bu.id
+        WHERE b.channel_id = h.channel_id AND b.thread_id = h.thread_id AND b.deleted IS NULL
+          AND bu.bot_id = @bot_id::uuid AND b.timestamp > h.timestamp
+          AND (h.next_human IS NULL OR b.timestamp < h.next_human)
+    ) AS first_reply
+    FROM human h
+)
+SELECT count(*) AS human_messages, count(first_reply) AS replied,
+       COALESCE(avg(EXTRACT(EPOCH FROM first_reply - timestamp)), 0)::float8 AS avg_response_seconds
+FROM replies;
@@ -0,0 +1,5 @@
+-- name: ListChannelTranscript :many
+SELECT id, provider_id, channel_id, author_id, content, timestamp, deleted, thread_id, parent_id FROM message WHERE channel_id = $1 AND deleted IS NULL ORDER BY timestamp;
+
+-- name: GetBotUser :one
+SELECT * FROM "user" WHERE provider = $1 AND bot_id = $2 LIMIT 1;
@@ -10,8 +10,13 @@ SELECT * FROM "user" WHERE provider = $1;
 -- name: ListUsersInChannel :many
 WITH channel_users AS (
   SELECT distinct author_id FROM message WHERE channel_id = $1
+  UNION
+  SELECT distinct r.user_id FROM reaction r JOIN message m ON r.message_id = m.id WHERE m.channel_id = $1
 )
 SELECT * FROM "user" WHERE id IN (SELECT author_id FROM channel_users);
 
 -- name: GetUser :one
-SELECT * FROM "user" WHERE id = $1;
\ No newline at end of file
+SELECT * FROM "user" WHERE id = $1;
+
+-- name: GetUserByProviderID :one
+SELECT * FROM "user" WHERE provider = $1 AND provider_id = $2;
\ No newline at end of file
@@ -7,6 +7,7 @@ package db
 import (
 	"database/sql"
 	"database/sql/driver"
+	"encoding/json"
 	"fmt"
 	"time"
 
@@ -57,6 +58,41 @@ func (ns NullProvider) Value() (driver.Value, error) {
 	return string(ns.Provider), nil
 }
 
+type Attachment struct {
+	ID          uuid.UUID
+	MessageID   uuid.UUID
+	Position    int32
+	Type        string
+	Name        string
+	URL         string
+	MimeType    string
+	Size        int64
+	Description string
+	Text        string
+}
+
+type Autopilot struct {
+	ChannelID    uuid.UUID
+	Topic        string
+	MaxRounds    int32
+	Rounds       int32
+	TokenBudget  int32
+	Tokens       int32
+	PauseSeconds int32
+	Started      time.Time
+	RoundStarted time.Time
+	Stopped      sql.NullTime
+	StopReason   string
+}
+
+type Backfill struct {
+	ChannelID  uuid.UUID
+	NextCursor string
+	Messages   int32
+	Completed  bool
+	Updated    time.Time
+}
+
 type BotChannel struct {
 	Bot      uuid.UUID
 	Channel  uuid.UUID
@@ -70,18 +106,90 @@ type Channel struct {
 	Provider   Provider
 	Name       string
 	Deleted    sql.NullTime
+	DmUser     string
+	GuildID    string
+}
+
+type ChannelSetting struct {
+	ChannelID          uuid.UUID
+	ReplyProbability   float64
+	CooldownSeconds    int32
+	MaxMessagesPerHour int32
+	QuietStart         string
+	QuietEnd           string
+	Timezone           string
+	MentionsOnly       bool
+	Updated            time.Time
+	RetentionDays      int32
+}
+
+type ForgottenUser struct {
+	Provider   Provider
+	ProviderID string
+	UserID     *uuid.UUID
+	Forgotten  time.Time
 }
 
 type Message struct {
+	ID           uuid.UUID
+	ProviderID   string
+	ChannelID    uuid.UUID
+	AuthorID     uuid.UUID
+	Content      string
+	Timestamp    time.Time
+	Deleted      sql.NullTime
+	ThreadID     string
+	ParentID     string
+	SearchVector string `json:"-"`
+}
+
+type Reaction struct {
+	ID        uuid.UUID
+	MessageID uuid.UUID
+	UserID    uuid.UUID
+	Emoji     string
+	Timestamp time.Time
+}
+
+type Scenario struct {
 	ID         uuid.UUID
-	ProviderID string
-	ChannelID  uuid.UUID
-	AuthorID   uuid.UUID
-	Content    string
-	Timestamp  time.Time
+	Name       string
+	Definition json.RawMessage
+	Created    time.Time
 	Deleted    sql.NullTime
 }
 
+type ScenarioRun struct {
+	ChannelID  uuid.UUID
+	ScenarioID uuid.UUID
+	Beat       int32
+	Status     string
+	NextAt     sql.NullTime
+	Updated    time.Time
+}
+
+type Schedule struct {
+	ID            uuid.UUID
+	ChannelID     uuid.UUID
+	Name          string
+	Cron          string
+	Timezone      string
+	JitterSeconds int32
+	WindowStart   string
+	WindowEnd     string
+	Instruction   string
+	Disabled      bool
+	NextRun       time.Time
+	LastRun       sql.NullTime
+	Created       time.Time
+	Deleted       sql.NullTime
+	ChannelClosed bool
+}
+
+type SlackIDRepair struct {
+	ChannelID uuid.UUID
+}
+
 type User struct {
 	ID         uuid.UUID
 	Provider   Provider
@@ -6,32 +6,107 @@ package db
 
 import (
 	"context"
+	"database/sql"
+	"encoding/json"
+	"time"
 
 	"encore.dev/types/uuid"
 )
 
 type Querier interface {
+	AddAutopilotTokens(ctx context.Context, db DBTX, arg AddAutopilotTokensParams) error
+	AnonymizeUser(ctx context.Context, db DBTX, arg AnonymizeUserParams) error
+	BotMessagesPerDay(ctx context.Context, db DBTX, arg BotMessagesPerDayParams) ([]*BotMessagesPerDayRow, error)
+	BotReplyStats(ctx context.Context, db DBTX, arg BotReplyStatsParams) (*BotReplyStatsRow, error)
+	ChannelMessagesPerDay(ctx context.Context, db DBTX, arg ChannelMessagesPerDayParams) ([]*ChannelMessagesPerDayRow, error)
+	ChannelReplyStats(ctx context.Context, db DBTX, arg ChannelReplyStatsParams) (*ChannelReplyStatsRow, error)
+	CompleteSlackIDRepair(ctx context.Context, db DBTX, channelID uuid.UUID) error
+	CountActiveHumansInChannel(ctx context.Context, db DBTX, arg CountActiveHumansInChannelParams) (int64, error)
+	CountBotMessagesSince(ctx context.Context, db DBTX, arg CountBotMessagesSinceParams) (int64, error)
+	CountMessagesByAuthor(ctx context.Context, db DBTX, authorID uuid.UUID) (int64, error)
+	DeleteChannel(ctx context.Context, db DBTX, id uuid.UUID) error
+	DeleteGuildChannels(ctx context.Context, db DBTX, arg DeleteGuildChannelsParams) error
+	DeleteLinkAttachments(ctx context.Context, db DBTX, arg DeleteLinkAttachmentsParams) error
+	DeleteMessage(ctx context.Context, db DBTX, arg DeleteMessageParams) error
+	DeleteReaction(ctx context.Context, db DBTX, arg DeleteReactionParams) error
+	DeleteReactionsByUser(ctx context.Context, db DBTX, userID uuid.UUID) error
+	DeleteScenario(ctx context.Context, db DBTX, id uuid.UUID) (int64, error)
+	DeleteSchedule(ctx context.Context, db DBTX, id uuid.UUID) (int64, error)
+	DeleteUser(ctx context.Context, db DBTX, id uuid.UUID) error
+	DisableChannelSchedules(ctx context.Context, db DBTX, channelID uuid.UUID) error
+	EnableChannelSchedules(ctx context.Context, db DBTX, channelID uuid.UUID) ([]*Schedule, error)
+	GetAutopilot(ctx context.Context, db DBTX, channelID uuid.UUID) (*Autopilot, error)
+	GetBackfill(ctx context.Context, db DBTX, channelID uuid.UUID) (*Backfill, error)
 	GetBotChannel(ctx context.Context, db DBTX, arg GetBotChannelParams) (uuid.UUID, error)
+	GetBotUser(ctx context.Context, db DBTX, arg GetBotUserParams) (*User, error)
 	GetChannel(ctx context.Context, db DBTX, id uuid.UUID) (*Channel, error)
 	GetChannelByProviderID(ctx context.Context, db DBTX, arg GetChannelByProviderIDParams) (*Channel, error)
 	GetChannelByProviderId(ctx context.Context, db DBTX, arg GetChannelByProviderIdParams) (*Channel, error)
+	GetChannelSettings(ctx context.Context, db DBTX, channelID uuid.UUID) (*ChannelSetting, error)
+	GetScenario(ctx context.Context, db DBTX, id uuid.UUID) (*Scenario, error)
+	GetScenarioDefinition(ctx context.Context, db DBTX, id uuid.UUID) (json.RawMessage, error)
+	GetScenarioRun(ctx context.Context, db DBTX, channelID uuid.UUID) (*ScenarioRun, error)
+	GetSchedule(ctx context.Context, db DBTX, id uuid.UUID) (*Schedule, error)
 	GetUser(ctx context.Context, db DBTX, id uuid.UUID) (*User, error)
-	InsertMessage(ctx context.Context, db DBTX, arg InsertMessageParams) (*Message, error)
+	GetUserByProviderID(ctx context.Context, db DBTX, arg GetUserByProviderIDParams) (*User, error)
+	InsertAttachment(ctx context.Context, db DBTX, arg InsertAttachmentParams) error
+	InsertMessage(ctx context.Context, db DBTX, arg InsertMessageParams) (*InsertMessageRow, error)
+	InsertReaction(ctx context.Context, db DBTX, arg InsertReactionParams) error
+	InsertScenario(ctx context.Context, db DBTX, arg InsertScenarioParams) (*Scenario, error)
+	InsertSchedule(ctx context.Context, db DBTX, arg InsertScheduleParams) (*Schedule, error)
 	InsertUser(ctx context.Context, db DBTX, arg InsertUserParams) (*User, error)
-	LatestBotMessageInChannel(ctx context.Context, db DBTX, channelID uuid.UUID) (*Message, error)
-	LatestMessageInChannel(ctx context.Context, db DBTX, channelID uuid.UUID) (*Message, error)
+	LatestBotMessageInChannel(ctx context.Context, db DBTX, channelID uuid.UUID) (*LatestBotMessageInChannelRow, error)
+	LatestMessageInChannel(ctx context.Context, db DBTX, channelID uuid.UUID) (*LatestMessageInChannelRow, error)
+	ListActiveAutopilots(ctx context.Context, db DBTX) ([]*Autopilot, error)
+	ListAttachmentsForMessages(ctx context.Context, db DBTX, messageIds []uuid.UUID) ([]*Attachment, error)
 	ListBotsInChannel(ctx context.Context, db DBTX, channel uuid.UUID) ([]uuid.UUID, error)
+	ListChannelRetention(ctx context.Context, db DBTX) ([]*ListChannelRetentionRow, error)
+	ListChannelTranscript(ctx context.Context, db DBTX, channelID uuid.UUID) ([]*ListChannelTranscriptRow, error)
 	ListChannels(ctx context.Context, db DBTX) ([]*Channel, error)
 	ListChannelsByProvider(ctx context.Context, db DBTX, provider Provider) ([]*Channel, error)
 	ListChannelsWithBots(ctx context.Context, db DBTX) ([]*Channel, error)
-	ListMessagesInChannel(ctx context.Context, db DBTX, channelID uuid.UUID) ([]*Message, error)
-	ListMessagesInChannelAfter(ctx context.Context, db DBTX, arg ListMessagesInChannelAfterParams) ([]*Message, error)
+	ListDueScenarioRuns(ctx context.Context, db DBTX) ([]*ScenarioRun, error)
+	ListDueSchedules(ctx context.Context, db DBTX) ([]*Schedule, error)
+	ListForgottenUsers(ctx context.Context, db DBTX, provider Provider) ([]*ForgottenUser, error)
+	ListGuildChannels(ctx context.Context, db DBTX, arg ListGuildChannelsParams) ([]*Channel, error)
+	ListMessagesInChannel(ctx context.Context, db DBTX, channelID uuid.UUID) ([]*ListMessagesInChannelRow, error)
+	ListMessagesInChannelAfter(ctx context.Context, db DBTX, arg ListMessagesInChannelAfterParams) ([]*ListMessagesInChannelAfterRow, error)
+	ListMessagesInThread(ctx context.Context, db DBTX, arg ListMessagesInThreadParams) ([]*ListMessagesInThreadRow, error)
+	ListReactionsInChannel(ctx context.Context, db DBTX, channelID uuid.UUID) ([]*Reaction, error)
+	ListScenarios(ctx context.Context, db DBTX) ([]*Scenario, error)
+	ListScheduledChannels(ctx context.Context, db DBTX) ([]uuid.UUID, error)
+	ListSchedulesInChannel(ctx context.Context, db DBTX, channelID uuid.UUID) ([]*Schedule, error)
 	ListUsers(ctx context.Context, db DBTX) ([]*User, error)
 	ListUsersByProvider(ctx context.Context, db DBTX, provider Provider) ([]*User, error)
+	ListUsersByProviderID(ctx context.Context, db DBTX, arg ListUsersByProviderIDParams) ([]*User, error)
 	ListUsersInChannel(ctx context.Context, db DBTX, channelID uuid.UUID) ([]*User, error)
+	MarkLegacySlackMessages(ctx context.Context, db DBTX, channelID uuid.UUID) (int64, error)
+	OldestLegacySlackMessage(ctx context.Context, db DBTX, channelID uuid.UUID) (time.Time, error)
+	PurgeDeletedBotChannels(ctx context.Context, db DBTX, before sql.NullTime) (int64, error)
+	PurgeDeletedMessages(ctx context.Context, db DBTX, before sql.NullTime) (int64, error)
+	PurgeMessagesBefore(ctx context.Context, db DBTX, arg PurgeMessagesBeforeParams) (int64, error)
+	PurgeMessagesByAuthor(ctx context.Context, db DBTX, authorID uuid.UUID) (int64, error)
+	PurgeOrphanedUsers(ctx context.Context, db DBTX) (int64, error)
+	RecordForgottenUser(ctx context.Context, db DBTX, arg RecordForgottenUserParams) error
 	RemoveBotChannel(ctx context.Context, db DBTX, arg RemoveBotChannelParams) (uuid.UUID, error)
+	RemoveChannelBots(ctx context.Context, db DBTX, channel uuid.UUID) ([]uuid.UUID, error)
+	RestoreChannel(ctx context.Context, db DBTX, arg RestoreChannelParams) error
+	SearchMessages(ctx context.Context, db DBTX, arg SearchMessagesParams) ([]*SearchMessagesRow, error)
+	SetLegacySlackMessageID(ctx context.Context, db DBTX, arg SetLegacySlackMessageIDParams) (int64, error)
+	SetScheduleNextRun(ctx context.Context, db DBTX, arg SetScheduleNextRunParams) error
+	SetScheduleRun(ctx context.Context, db DBTX, arg SetScheduleRunParams) error
+	SlackIDRepairPending(ctx context.Context, db DBTX, channelID uuid.UUID) (bool, error)
+	StartAutopilotRound(ctx context.Context, db DBTX, channelID uuid.UUID) error
+	StopAutopilot(ctx context.Context, db DBTX, arg StopAutopilotParams) (int64, error)
+	UpdateMessageContent(ctx context.Context, db DBTX, arg UpdateMessageContentParams) error
+	UpdateScenarioRun(ctx context.Context, db DBTX, arg UpdateScenarioRunParams) error
+	UpdateSchedule(ctx context.Context, db DBTX, arg UpdateScheduleParams) (*Schedule, error)
+	UpsertAutopilot(ctx context.Context, db DBTX, arg UpsertAutopilotParams) (*Autopilot, error)
+	UpsertBackfill(ctx context.Context, db DBTX, arg UpsertBackfillParams) error
 	UpsertBotChannel(ctx context.Context, db DBTX, arg UpsertBotChannelParams) (uuid.UUID, error)
 	UpsertChannel(ctx context.Context, db DBTX, arg UpsertChannelParams) (*Channel, error)
+	UpsertChannelSettings(ctx context.Context, db DBTX, arg UpsertChannelSettingsParams) (*ChannelSetting, error)
+	UpsertScenarioRun(ctx context.Context, db DBTX, arg UpsertScenarioRunParams) (*ScenarioRun, error)
 }
 
 var _ Querier = (*Queries)(nil)
@@ -29,6 +29,29 @@ func (q *Queries) GetUser(ctx context.Context, db DBTX, id uuid.UUID) (*User, er
 	return &i, err
 }
 
+const getUserByProviderID = `-- name: GetUserByProviderID :one
+SELECT id, provider, provider_id, name, profile, bot_id FROM "user" WHERE provider = $1 AND provider_id = $2
+`
+
+type GetUserByProviderIDParams struct {
+	Provider   Provider
+	ProviderID string
+}
+
+func (q *Queries) GetUserByProviderID(ctx context.Context, db DBTX, arg GetUserByProviderIDParams) (*User, error) {
+	row := db.QueryRowContext(ctx, getUserByProviderID, arg.Provider, arg.ProviderID)
+	var i User
+	err := row.Scan(
+		&i.ID,
+		&i.Provider,
+		&i.ProviderID,
+		&i.Name,
+		&i.Profile,
+		&i.BotID,
+	)
+	return &i, err
+}
+
 const insertUser = `-- name: InsertUser :one
 INSERT INTO "user" (id, provider, provider_id, name, profile, bot_id) VALUES (gen_random_uuid(), $1, $2, $3, $4, $5) RETURNING id, provider, provider_id, name, profile, bot_id
 `
@@ -132,6 +155,8 @@ func (q *Queries) ListUsersByProvider(ctx context.Context, db DBTX, provider Pro
 const listUsersInChannel = `-- name: ListUsersInChannel :many
 WITH channel_users AS (
   SELECT distinct author_id FROM message WHERE channel_id = $1
+  UNION
+  SELECT distinct r.user_id FROM reaction r JOIN message m ON r.message_id = m.id WHERE m.channel_id = $1
 )
 SELECT id, provider, provider_id, name, profile, bot_id FROM "user" WHERE id IN (SELECT author_id FROM channel_users)
 `
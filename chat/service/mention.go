@@ -0,0 +1,222 @@
+package chat
+
+import (
+	"context"
+	"regexp"
+	"slices"
+	"sort"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/cockroachdb/errors"
+
+	botdb "encore.app/bot/db"
+	"encore.app/chat/service/client"
+	"encore.app/chat/service/db"
+	fns "encore.app/pkg/fns"
+	"encore.dev/rlog"
+	"encore.dev/types/uuid"
+)
+
+// mentionSyntax describes how a provider formats mentions of users.
+type mentionSyntax struct {
+	// pattern matches a mention, the first group is the provider ID of the mentioned user
+	pattern *regexp.Regexp
+	// userID matches the provider IDs of users that can be mentioned
+	userID *regexp.Regexp
+	format func(id string) string
+}
+
+// mentionSyntaxes are the mention formats of the providers which use IDs in mentions. Mentions in the local chat
+// are plain names, so it doesn't need any translation.
+var mentionSyntaxes = map[db.Provider]mentionSyntax{
+	// e.g. <@U024BE7LH> or <@U024BE7LH|bob>
+	db.ProviderSlack: {
+		pattern: regexp.MustCompile(`<@([UW][A-Z0-9]+)(?:\|[^>]*)?>`),
+		userID:  regexp.MustCompile(`^[UW][A-Z0-9]+$`),
+		format:  func(id string) string { return "<@" + id + ">" },
+	},
+	// e.g. <@80351110224678912> or <@!80351110224678912>
+	db.ProviderDiscord: {
+		pattern: regexp.MustCompile(`<@!?(\d+)>`),
+		userID:  regexp.MustCompile(`^\d+$`),
+		format:  func(id string) string { return "<@" + id + ">" },
+	},
+}
+
+// mentionResolver translates mentions between the provider-native syntax, which uses user IDs, and the @Name
+// syntax used by the LLMs.
+type mentionResolver struct {
+	ctx    context.Context
+	prov   client.Client
+	syntax mentionSyntax
+	// names maps provider user IDs to names
+	names map[string]string
+	// ids maps lowercase names of users that can be mentioned to their provider IDs
+	ids map[string]string
+	// mentionable contains the names in ids, longest first to match "@Ann Lee" before "@Ann"
+	mentionable []string
+}
+
+// newMentionResolver creates a mention resolver for a provider. It returns nil if the provider doesn't need any
+// mention translation. Mentions of bot names are always left as is, most bots can't be mentioned natively.
+func (svc *Service) newMentionResolver(ctx context.Context, providerName db.Provider, bots []*botdb.Bot) (*mentionResolver, error) {
+	syntax, ok := mentionSyntaxes[providerName]
+	if !ok {
+		return nil, nil
+	}
+	prov, ok := svc.providers[providerName]
+	if !ok {
+		return nil, errors.New("provider not found")
+	}
+	users, err := db.New().ListUsersByProvider(ctx, chatdb.Stdlib(), providerName)
+	if err != nil {
+		return nil, errors.Wrap(err, "list users by provider")
+	}
+	botNames := make(map[string]bool, len(bots))
+	for _, b := range bots {
+		botNames[strings.ToLower(b.Name)] = true
+	}
+	m := &mentionResolver{
+		ctx:    ctx,
+		prov:   prov,
+		syntax: syntax,
+		names:  make(map[string]string, len(users)),
+		ids:    make(map[string]string, len(users)),
+	}
+	for _, u := range users {
+		m.names[u.ProviderID] = u.Name
+		name := strings.ToLower(u.Name)
+		if u.BotID != nil || name == "" || botNames[name] || !syntax.userID.MatchString(u.ProviderID) {
+			continue
+		}
+		if _, ok := m.ids[name]; ok {
+			// The name is ambiguous, keep the first user
+			continue
+		}
+		m.ids[name] = u.ProviderID
+		m.mentionable = append(m.mentionable, name)
+	}
+	sort.Slice(m.mentionable, func(i, j int) bool { return len(m.mentionable[i]) > len(m.mentionable[j]) })
+	return m, nil
+}
+
+// toNames rewrites native mentions to @Name. Users that aren't in the database are looked up in the provider,
+// mentions of unknown users are left as is.
+func (m *mentionResolver) toNames(content string) string {
+	if m == nil {
+		return content
+	}
+	return m.syntax.pattern.ReplaceAllStringFunc(content, func(mention string) string {
+		id := m.syntax.pattern.FindStringSubmatch(mention)[1]
+		name, ok := m.names[id]
+		if !ok {
+			user, err := m.prov.GetUser(m.ctx, id)
+			if err != nil || user == nil {
+				rlog.Debug("unknown mentioned user", "id", id, "error", err)
+				m.names[id] = ""
+				return mention
+			}
+			name = user.Name
+			m.names[id] = name
+		}
+		if name == "" {
+			return mention
+		}
+		return "@" + name
+	})
+}
+
+// toNative rewrites @Name mentions of users to the provider-native mention syntax. Names are matched case
+// insensitively and must not be surrounded by letters or digits.
+func (m *mentionResolver) toNative(content string) string {
+	if m == nil || len(m.mentionable) == 0 || !strings.Contains(content, "@") {
+		return content
+	}
+	var res strings.Builder
+	last := 0
+	for i := 0; i < len(content); i++ {
+		if content[i] != '@' {
+			continue
+		}
+		// Skip e.g. email addresses
+		if prev, _ := utf8.DecodeLastRuneInString(content[:i]); unicode.IsLetter(prev) || unicode.IsDigit(prev) {
+			continue
+		}
+		for _, name := range m.mentionable {
+			end := i + 1 + len(name)
+			if end > len(content) || !strings.EqualFold(content[i+1:end], name) {
+				continue
+			}
+			if next, _ := utf8.DecodeRuneInString(content[end:]); unicode.IsLetter(next) || unicode.IsDigit(next) {
+				continue
+			}
+			res.WriteString(content[last:i])
+			res.WriteString(m.syntax.format(m.ids[name]))
+			last = end
+			i = end - 1
+			break
+		}
+	}
+	res.WriteString(content[last:])
+	return res.String()
+}
+
+// resolveMentions returns a copy of the messages with native mentions rewritten to names. The stored messages
+// keep the native syntax.
+func (m *mentionResolver) resolveMentions(msgs []*db.Message) []*db.Message {
+	if m == nil {
+		return msgs
+	}
+	rtn := make([]*db.Message, len(msgs))
+	for i, msg := range msgs {
+		rtn[i] = msg
+		if content := m.toNames(msg.Content); content != msg.Content {
+			cp := *msg
+			cp.Content = content
+			rtn[i] = &cp
+		}
+	}
+	return rtn
+}
+
+// addressedBots returns the bots a message is addressed to, either with an @Name mention anywhere in the message
+// or by starting the message with the bot's name, e.g. "Grumpy, what do you think?". Like in toNative, the longest
+// name wins, so "@Ann Lee" doesn't address Ann, and mentions preceded by a letter or digit, e.g. email addresses,
+// are skipped. Native mentions must be rewritten to names first.
+func addressedBots(content string, bots []*botdb.Bot) []*botdb.Bot {
+	longestFirst := slices.Clone(bots)
+	sort.SliceStable(longestFirst, func(i, j int) bool { return len(longestFirst[i].Name) > len(longestFirst[j].Name) })
+	addressed := map[uuid.UUID]bool{}
+	match := func(i int) {
+		for _, b := range longestFirst {
+			if b.Name != "" && nameAt(content, i, b.Name) {
+				addressed[b.ID] = true
+				return
+			}
+		}
+	}
+	match(len(content) - len(strings.TrimLeftFunc(content, unicode.IsSpace)))
+	for i := 0; i < len(content); i++ {
+		if content[i] != '@' {
+			continue
+		}
+		if prev, _ := utf8.DecodeLastRuneInString(content[:i]); unicode.IsLetter(prev) || unicode.IsDigit(prev) {
+			continue
+		}
+		match(i + 1)
+	}
+	return fns.Filter(bots, func(b *botdb.Bot) bool { return addressed[b.ID] })
+}
+
+// nameAt returns true if content contains name at index i, matched case insensitively and not followed by a
+// letter or digit.
+func nameAt(content string, i int, name string) bool {
+	end := i + len(name)
+	if end > len(content) || !strings.EqualFold(content[i:end], name) {
+		return false
+	}
+	next, _ := utf8.DecodeRuneInString(content[end:])
+	return !unicode.IsLetter(next) && !unicode.IsDigit(next)
+}
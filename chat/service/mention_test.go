@@ -0,0 +1,75 @@
+package chat
+
+import (
+	"sort"
+	"strings"
+	"testing"
+
+	botdb "encore.app/bot/db"
+	"encore.app/chat/service/db"
+	fns "encore.app/pkg/fns"
+	"encore.dev/types/uuid"
+)
+
+func TestToNative(t *testing.T) {
+	m := &mentionResolver{
+		syntax: mentionSyntaxes[db.ProviderSlack],
+		ids:    map[string]string{"ann": "U1", "ann lee": "U2", "élise": "U3"},
+	}
+	for name := range m.ids {
+		m.mentionable = append(m.mentionable, name)
+	}
+	sort.Slice(m.mentionable, func(i, j int) bool { return len(m.mentionable[i]) > len(m.mentionable[j]) })
+	tests := []struct {
+		content, want string
+	}{
+		{content: "no mentions", want: "no mentions"},
+		{content: "hi @Ann", want: "hi <@U1>"},
+		{content: "@ann lee, hi", want: "<@U2>, hi"},
+		{content: "@Ann Leeds", want: "<@U1> Leeds"},
+		{content: "@Annabel", want: "@Annabel"},
+		{content: "mail ann@ann.com", want: "mail ann@ann.com"},
+		{content: "@@Ann", want: "@<@U1>"},
+		{content: "@Bob", want: "@Bob"},
+		{content: "@Ann and @Élise", want: "<@U1> and <@U3>"},
+		{content: "@ÉLISE!", want: "<@U3>!"},
+		{content: "@Éliseé", want: "@Éliseé"},
+		{content: "café@Élise", want: "café@Élise"},
+	}
+	for _, tt := range tests {
+		if got := m.toNative(tt.content); got != tt.want {
+			t.Errorf("toNative(%q) = %q, want %q", tt.content, got, tt.want)
+		}
+	}
+}
+
+func TestAddressedBots(t *testing.T) {
+	bots := []*botdb.Bot{
+		{ID: uuid.FromStringOrNil("00000000-0000-0000-0000-000000000001"), Name: "Ann"},
+		{ID: uuid.FromStringOrNil("00000000-0000-0000-0000-000000000002"), Name: "Ann Lee"},
+		{ID: uuid.FromStringOrNil("00000000-0000-0000-0000-000000000003"), Name: "Grumpy"},
+		{ID: uuid.FromStringOrNil("00000000-0000-0000-0000-000000000004"), Name: "Élise"},
+	}
+	tests := []struct {
+		content string
+		want    []string
+	}{
+		{content: "what do you all think?", want: nil},
+		{content: "Grumpy, what do you think?", want: []string{"Grumpy"}},
+		{content: "  grumpy what do you think?", want: []string{"Grumpy"}},
+		{content: "Grumpyness is a word", want: nil},
+		{content: "I asked Grumpy", want: nil},
+		{content: "hey @ann lee", want: []string{"Ann Lee"}},
+		{content: "Ann Lee, and you @Ann?", want: []string{"Ann", "Ann Lee"}},
+		{content: "mail me at me@Grumpy.com", want: nil},
+		{content: "thanks @Grumpy", want: []string{"Grumpy"}},
+		{content: "@élise?", want: []string{"Élise"}},
+		{content: "@Élisée", want: nil},
+	}
+	for _, tt := range tests {
+		got := fns.Map(addressedBots(tt.content, bots), func(b *botdb.Bot) string { return b.Name })
+		if strings.Join(got, ",") != strings.Join(tt.want, ",") {
+			t.Errorf("addressedBots(%q) = %q, want %q", tt.content, got, tt.want)
+		}
+	}
+}
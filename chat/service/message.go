@@ -4,6 +4,7 @@ import (
 	"context"
 	"database/sql"
 	"slices"
+	"strings"
 	"time"
 
 	"github.com/cockroachdb/errors"
@@ -63,7 +64,7 @@ func (svc *Service) InstructBotInChannel(ctx context.Context, channelID uuid.UUI
 	if err != nil {
 		return errors.Wrap(err, "get channel")
 	}
-	err = svc.publishLLMTasks(ctx, llmprovider.TaskTypeInstruct, bots.Bots, channel, req.Instruction)
+	err = svc.publishLLMTasks(ctx, llmprovider.TaskTypeInstruct, bots.Bots, channel, "", req.Instruction)
 	return errors.Wrap(err, "publish llm task")
 
 }
@@ -78,12 +79,30 @@ func (svc *Service) ProcessLLMMessage(ctx context.Context, event *llmprovider.Bo
 	if !ok {
 		return errors.New("provider not found")
 	}
+	// Tasks which finished before their channel was archived or deleted can't be cancelled anymore
+	_, err := svc.GetChannel(ctx, event.Channel.ID)
+	if errors.Is(err, sql.ErrNoRows) {
+		rlog.Info("dropping bot response for closed channel", "channel", event.Channel.ID)
+		return nil
+	} else if err != nil {
+		return errors.Wrap(err, "get channel")
+	}
 	botIDs := fns.Map(event.Messages, func(m *llmprovider.BotMessage) uuid.UUID { return m.Bot })
 	bots, err := botsvc.List(ctx, &botsvc.ListBotRequest{IDs: botIDs})
 	if err != nil {
 		return errors.Wrap(err, "list bots")
 	}
 	botsByID := fns.ToMap(bots.Bots, func(b *botdb.Bot) uuid.UUID { return b.ID })
+	mentions, err := svc.newMentionResolver(ctx, event.Channel.Provider, bots.Bots)
+	if err != nil {
+		return errors.Wrap(err, "create mention resolver")
+	}
+	// Only sent messages count towards the token budget of an autopilot, it's checked once for all of them
+	autopilot, err := svc.hasActiveAutopilot(ctx, event.Channel.ID)
+	if err != nil {
+		return err
+	}
+	var tokens int32
 	pc := prov.GetChannelClient(ctx, event.Channel.ProviderID)
 	for _, msg := range event.Messages {
 		switch msg.Type {
@@ -92,17 +111,35 @@ func (svc *Service) ProcessLLMMessage(ctx context.Context, event *llmprovider.Bo
 			if err != nil {
 				rlog.Warn("send typing", "error", err)
 			}
+		case llmprovider.BotMessageTypeReaction:
+			err := pc.React(ctx, &provider.ReactRequest{
+				MessageID: msg.ReactTo.ProviderID,
+				ThreadID:  msg.ReactTo.ThreadID,
+				Emoji:     msg.Content,
+				Bot:       botsByID[msg.Bot],
+			})
+			if err != nil {
+				rlog.Warn("send reaction", "error", err)
+			}
 		default:
 			err := pc.Send(ctx, &provider.SendMessageRequest{
-				Content: msg.Content,
-				Bot:     botsByID[msg.Bot],
-				Type:    "message",
+				Content:  mentions.toNative(msg.Content),
+				Bot:      botsByID[msg.Bot],
+				Type:     "message",
+				ThreadID: event.ThreadID,
 			})
 			if err != nil {
 				rlog.Warn("send message", "error", err)
+			} else if autopilot {
+				tokens += estimateTokens(msg.Content)
 			}
 		}
 	}
+	if tokens > 0 {
+		if err := svc.countAutopilotTokens(ctx, event.Channel.ID, tokens); err != nil {
+			rlog.Warn("count autopilot tokens", "error", err)
+		}
+	}
 	if event.TaskType == llmprovider.TaskTypeLeave {
 		for _, b := range botsByID {
 			err := prov.GetChannelClient(ctx, event.Channel.ProviderID).Leave(ctx, b)
@@ -114,13 +151,18 @@ func (svc *Service) ProcessLLMMessage(ctx context.Context, event *llmprovider.Bo
 	return nil
 }
 
-// ProcessProviderEvent processes an event from a chat provider. It can be a message or a channel creation event.
+// ProcessProviderEvent processes an event from a chat provider. It can be a message, a message edit or deletion,
+// a reaction or a channel creation event.
 //
 //encore:api private path=/chat/events/provider method=POST
 func (svc *Service) ProcessProviderEvent(ctx context.Context, event *provider.Message) error {
 	switch event.Type {
 	case "channel_created":
 		return svc.ProcessProviderChannelCreated(ctx, event)
+	case provider.MessageTypeEdited, provider.MessageTypeDeleted:
+		return svc.ProcessProviderMessageChanged(ctx, event)
+	case provider.MessageTypeReactionAdded, provider.MessageTypeReactionRemoved:
+		return svc.ProcessProviderReaction(ctx, event)
 	default:
 		return svc.ProcessProviderMessage(ctx, event)
 	}
@@ -166,7 +208,93 @@ func (svc *Service) ProcessProviderChannelCreated(ctx context.Context, msg *prov
 			return errors.Wrap(err, "join channel")
 		}
 	}
-	return svc.publishLLMTasks(ctx, llmprovider.TaskTypePrepopulate, bots, channel, "")
+	return svc.publishLLMTasks(ctx, llmprovider.TaskTypePrepopulate, bots, channel, "", "")
+}
+
+// ProcessProviderMessageChanged processes an edited or deleted message from a chat provider. It updates the content
+// or soft deletes the stored message, so the bots see the conversation as it is in the provider. It doesn't trigger
+// any bot responses.
+//
+//encore:api private path=/chat/events/provider/message/changed method=POST
+func (svc *Service) ProcessProviderMessageChanged(ctx context.Context, msg *provider.Message) error {
+	q := db.New()
+	channel, err := q.GetChannelByProviderID(ctx, chatdb.Stdlib(), db.GetChannelByProviderIDParams{
+		ProviderID: msg.ChannelID,
+		Provider:   msg.Provider,
+	})
+	if errors.Is(err, sql.ErrNoRows) {
+		// We don't track the channel, so there is nothing to update
+		return nil
+	} else if err != nil {
+		return errors.Wrap(err, "get channel")
+	}
+	switch msg.Type {
+	case provider.MessageTypeEdited:
+		err = q.UpdateMessageContent(ctx, chatdb.Stdlib(), db.UpdateMessageContentParams{
+			Content:    msg.Content,
+			ChannelID:  channel.ID,
+			ProviderID: msg.ProviderID,
+		})
+		if err != nil {
+			return errors.Wrap(err, "update message content")
+		}
+		err = svc.replaceLinks(ctx, channel.ID, msg.ProviderID, msg.Attachments)
+		return errors.Wrap(err, "replace links")
+	case provider.MessageTypeDeleted:
+		err = q.DeleteMessage(ctx, chatdb.Stdlib(), db.DeleteMessageParams{
+			ChannelID:  channel.ID,
+			ProviderID: msg.ProviderID,
+		})
+		return errors.Wrap(err, "delete message")
+	}
+	return nil
+}
+
+// ProcessProviderReaction processes a reaction that was added or removed from a message in a chat provider. Reactions
+// are included as context when the bots continue the conversation, but don't trigger a response on their own.
+//
+//encore:api private path=/chat/events/provider/reaction method=POST
+func (svc *Service) ProcessProviderReaction(ctx context.Context, msg *provider.Message) error {
+	q := db.New()
+	channel, err := q.GetChannelByProviderID(ctx, chatdb.Stdlib(), db.GetChannelByProviderIDParams{
+		ProviderID: msg.ChannelID,
+		Provider:   msg.Provider,
+	})
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil
+	} else if err != nil {
+		return errors.Wrap(err, "get channel")
+	}
+	user, err := q.GetUserByProviderID(ctx, chatdb.Stdlib(), db.GetUserByProviderIDParams{
+		Provider:   msg.Provider,
+		ProviderID: msg.Author.ID,
+	})
+	if errors.Is(err, sql.ErrNoRows) {
+		user, err = svc.insertUser(ctx, msg.Provider, msg.Author)
+	}
+	if err != nil {
+		return errors.Wrap(err, "get user")
+	}
+	switch msg.Type {
+	case provider.MessageTypeReactionAdded:
+		err = q.InsertReaction(ctx, chatdb.Stdlib(), db.InsertReactionParams{
+			UserID:    user.ID,
+			Emoji:     msg.Content,
+			Timestamp: msg.Time,
+			ChannelID: channel.ID,
+			MessageID: msg.ParentID,
+		})
+		return errors.Wrap(err, "insert reaction")
+	case provider.MessageTypeReactionRemoved:
+		err = q.DeleteReaction(ctx, chatdb.Stdlib(), db.DeleteReactionParams{
+			ChannelID: channel.ID,
+			MessageID: msg.ParentID,
+			UserID:    user.ID,
+			Emoji:     msg.Content,
+		})
+		return errors.Wrap(err, "delete reaction")
+	}
+	return nil
 }
 
 // ProcessProviderMessage processes an inbound message from a chat provider. It inserts the message into the database
@@ -189,29 +317,85 @@ func (svc *Service) ProcessProviderMessage(ctx context.Context, msg *provider.Me
 	if author.BotID != nil {
 		return nil
 	}
+	// Autopilot conversations are between bots, they end as soon as a human joins in
+	err = svc.stopAutopilot(ctx, msgs[0].ChannelID, AutopilotStoppedHuman)
+	if err != nil {
+		return err
+	}
+	// A scenario waiting for a human continues with its next beat
+	err = svc.scenarioHumanReplied(ctx, msgs[0].ChannelID)
+	if err != nil {
+		return err
+	}
+	channel, err := svc.GetChannel(ctx, msgs[0].ChannelID)
+	if err != nil {
+		return errors.Wrap(err, "get channel")
+	}
 	botIDs, err := q.ListBotsInChannel(ctx, chatdb.Stdlib(), msgs[0].ChannelID)
 	if err != nil {
 		return errors.Wrap(err, "list bots in channel")
 	}
+	if channel.DmUser != "" {
+		return svc.processDirectMessage(ctx, channel, botIDs, msgs[0])
+	}
 	if len(botIDs) == 0 {
 		return nil
 	}
-	bots, err := botsvc.List(ctx, &botsvc.ListBotRequest{IDs: botIDs})
+	resp, err := botsvc.List(ctx, &botsvc.ListBotRequest{IDs: botIDs})
 	if err != nil {
 		return errors.Wrap(err, "list bots")
 	}
-	channel, err := svc.GetChannel(ctx, msgs[0].ChannelID)
+	bots, err := svc.botsToReply(ctx, channel, resp.Bots, msgs[0])
 	if err != nil {
-		return errors.Wrap(err, "get channel")
+		return errors.Wrap(err, "apply channel settings")
+	}
+	if len(bots) == 0 {
+		return nil
 	}
-	err = svc.publishLLMTasks(ctx, llmprovider.TaskTypeContinue, bots.Bots, channel, "")
+	err = svc.publishLLMTasks(ctx, llmprovider.TaskTypeContinue, bots, channel, msgs[0].ThreadID, "")
+	return errors.Wrap(err, "publish llm task")
+}
+
+// processDirectMessage lets a single bot reply to a message in a direct message channel. The first message in a
+// new DM channel assigns the bot: the one mentioned by name in the message, or a random one otherwise.
+func (svc *Service) processDirectMessage(ctx context.Context, channel *db.Channel, botIDs []uuid.UUID, msg *db.Message) error {
+	resp, err := botsvc.List(ctx, &botsvc.ListBotRequest{IDs: botIDs})
+	if err != nil {
+		return errors.Wrap(err, "list bots")
+	}
+	bots := resp.Bots
+	if len(botIDs) == 0 {
+		bots = fns.Filter(resp.Bots, func(b *botdb.Bot) bool {
+			return strings.Contains(strings.ToLower(msg.Content), strings.ToLower(b.Name))
+		})
+		if len(bots) == 0 {
+			bots = resp.Bots
+		}
+		bots = fns.SelectRandom(bots, 1)
+		if len(bots) == 0 {
+			return nil
+		}
+		_, err = db.New().UpsertBotChannel(ctx, chatdb.Stdlib(), db.UpsertBotChannelParams{
+			Bot:      bots[0].ID,
+			Channel:  channel.ID,
+			Provider: channel.Provider,
+		})
+		if err != nil {
+			return errors.Wrap(err, "upsert bot channel")
+		}
+	}
+	if len(bots) == 0 {
+		return nil
+	}
+	err = svc.publishLLMTasks(ctx, llmprovider.TaskTypeDirect, bots[:1], channel, msg.ThreadID, "")
 	return errors.Wrap(err, "publish llm task")
 }
 
 // publishLLMTasks send tasks to the LLM provider to handle a specific event in a channel. It sends the task to all
-// providers that have bots in the channel.
-func (svc *Service) publishLLMTasks(ctx context.Context, typ llmprovider.TaskType, bots []*botdb.Bot, channel *db.Channel, adminPrompt string) error {
-	msgs, err := svc.getChannelHistory(ctx, channel.ID)
+// providers that have bots in the channel. If threadID is set, the history is scoped to the thread and the bots
+// reply in the thread.
+func (svc *Service) publishLLMTasks(ctx context.Context, typ llmprovider.TaskType, bots []*botdb.Bot, channel *db.Channel, threadID, adminPrompt string) error {
+	msgs, err := svc.getChannelHistory(ctx, channel.ID, threadID)
 	if err != nil {
 		return errors.Wrap(err, "get channel history")
 	}
@@ -219,6 +403,20 @@ func (svc *Service) publishLLMTasks(ctx context.Context, typ llmprovider.TaskTyp
 	if err != nil {
 		return errors.Wrap(err, "get channel users")
 	}
+	reactions, err := db.New().ListReactionsInChannel(ctx, chatdb.Stdlib(), channel.ID)
+	if err != nil {
+		return errors.Wrap(err, "list reactions")
+	}
+	attachments, err := svc.listAttachments(ctx, msgs)
+	if err != nil {
+		return errors.Wrap(err, "list attachments")
+	}
+	// The LLMs only know users by name, so mentions are rewritten from provider IDs to names
+	mentions, err := svc.newMentionResolver(ctx, channel.Provider, bots)
+	if err != nil {
+		return errors.Wrap(err, "create mention resolver")
+	}
+	msgs = mentions.resolveMentions(msgs)
 
 	botsByProvider := make(map[string][]*botdb.Bot)
 	for _, b := range bots {
@@ -226,13 +424,16 @@ func (svc *Service) publishLLMTasks(ctx context.Context, typ llmprovider.TaskTyp
 	}
 	for prov, bots := range botsByProvider {
 		_, err := llm.TaskTopic.Publish(ctx, &llmprovider.ChatRequest{
-			Bots:      bots,
-			Users:     users,
-			Channel:   channel,
-			Messages:  msgs,
-			SystemMsg: adminPrompt,
-			Provider:  prov,
-			Type:      typ,
+			Bots:        bots,
+			Users:       users,
+			Channel:     channel,
+			Messages:    msgs,
+			Reactions:   reactions,
+			Attachments: attachments,
+			ThreadID:    threadID,
+			SystemMsg:   adminPrompt,
+			Provider:    prov,
+			Type:        typ,
 		},
 		)
 		if err != nil {
@@ -242,25 +443,6 @@ func (svc *Service) publishLLMTasks(ctx context.Context, typ llmprovider.TaskTyp
 	return nil
 }
 
-// loadChannelHistory loads the message history for a channel from the provider and inserts it into the database.
-// It retrieves the latest message from the database and fetches all messages from the provider that are newer.
-func (svc *Service) loadChannelHistory(ctx context.Context, channel *db.Channel) ([]*db.Message, error) {
-	queries := db.New()
-	msg, err := queries.LatestMessageInChannel(ctx, chatdb.Stdlib(), channel.ID)
-	if !errors.Is(err, sql.ErrNoRows) && err != nil {
-		return nil, errors.Wrap(err, "latest message in channel")
-	}
-	prov, ok := svc.providers[channel.Provider]
-	if !ok {
-		return nil, errors.New("provider not found")
-	}
-	messages, err := prov.GetChannelClient(ctx, channel.ProviderID).ListMessages(ctx, msg)
-	if err != nil {
-		return nil, errors.Wrap(err, "list messages")
-	}
-	return svc.handleProviderMessages(ctx, channel.Provider, messages...)
-}
-
 // handleProviderMessages inserts messages, authors and channels from a provider into the database.
 // It's used when loading the history of a channel or when processing inbound messages.
 func (svc *Service) handleProviderMessages(ctx context.Context, providerName db.Provider, messages ...*provider.Message) ([]*db.Message, error) {
@@ -274,6 +456,11 @@ func (svc *Service) handleProviderMessages(ctx context.Context, providerName db.
 		return nil, errors.Wrap(err, "list users by provider")
 	}
 	userByID := fns.ToMap(users, func(u *db.User) provider.UserID { return u.ProviderID })
+	forgotten, err := q.ListForgottenUsers(ctx, chatdb.Stdlib(), providerName)
+	if err != nil {
+		return nil, errors.Wrap(err, "list forgotten users")
+	}
+	forgottenByID := fns.ToMap(forgotten, func(u *db.ForgottenUser) provider.UserID { return u.ProviderID })
 	channels, err := q.ListChannelsByProvider(ctx, chatdb.Stdlib(), providerName)
 	if err != nil {
 		return nil, errors.Wrap(err, "list channels by provider")
@@ -283,25 +470,16 @@ func (svc *Service) handleProviderMessages(ctx context.Context, providerName db.
 	var insertedMessages []*db.Message
 	for _, msg := range messages {
 		author, ok := userByID[msg.Author.ID]
-		if !ok {
-			params := db.InsertUserParams{
-				Provider:   providerName,
-				ProviderID: msg.Author.ID,
-				Name:       msg.Author.Name,
+		// Messages which users sent before they were forgotten are loaded again by backfills. They're dropped, or
+		// kept by the anonymized user if the user was anonymized.
+		if f, isForgotten := forgottenByID[msg.Author.ID]; isForgotten && msg.Time.Before(f.Forgotten) {
+			if f.UserID == nil {
+				continue
 			}
-			if msg.Author.BotID != uuid.Nil {
-				params.BotID = &msg.Author.BotID
-			} else {
-				user, err := prov.GetUser(ctx, msg.Author.ID)
-				if err != nil {
-					return nil, errors.Wrap(err, "get user")
-				}
-				if user != nil {
-					params.Profile = user.Profile
-					params.Name = user.Name
-				}
-			}
-			author, err = q.InsertUser(ctx, chatdb.Stdlib(), params)
+			author, ok = &db.User{ID: *f.UserID}, true
+		}
+		if !ok {
+			author, err = svc.insertUser(ctx, providerName, msg.Author)
 			if err != nil {
 				return nil, errors.Wrap(err, "insert user")
 			}
@@ -317,6 +495,11 @@ func (svc *Service) handleProviderMessages(ctx context.Context, providerName db.
 			if err != nil {
 				return nil, errors.Wrap(err, "insert channel")
 			}
+			channelByID[msg.ChannelID] = channel
+		}
+		// Messages of archived and deleted channels are dropped until the channel is restored
+		if channel.Deleted.Valid {
+			continue
 		}
 		dbMsg, err := q.InsertMessage(ctx, chatdb.Stdlib(), db.InsertMessageParams{
 			ChannelID:  channel.ID,
@@ -324,22 +507,70 @@ func (svc *Service) handleProviderMessages(ctx context.Context, providerName db.
 			Content:    msg.Content,
 			Timestamp:  msg.Time,
 			ProviderID: msg.ProviderID,
+			ThreadID:   msg.ThreadID,
+			ParentID:   msg.ParentID,
 		})
 		if errors.Is(err, sql.ErrNoRows) {
 			continue
 		} else if err != nil {
 			return nil, errors.Wrap(err, "insert message")
 		}
-		insertedMessages = append(insertedMessages, dbMsg)
+		err = svc.insertAttachments(ctx, channel.ID, msg.ProviderID, msg.Attachments)
+		if err != nil {
+			return nil, errors.Wrap(err, "insert attachments")
+		}
+		insertedMessages = append(insertedMessages, db.ToMessage(dbMsg))
 	}
 	return insertedMessages, nil
 }
 
+// insertUser inserts a provider user into the database. The profile of users which aren't bots is fetched from the
+// provider.
+func (svc *Service) insertUser(ctx context.Context, providerName db.Provider, u provider.User) (*db.User, error) {
+	prov, ok := svc.providers[providerName]
+	if !ok {
+		return nil, errors.New("provider not found")
+	}
+	params := db.InsertUserParams{
+		Provider:   providerName,
+		ProviderID: u.ID,
+		Name:       u.Name,
+	}
+	if u.BotID != uuid.Nil {
+		params.BotID = &u.BotID
+	} else {
+		user, err := prov.GetUser(ctx, u.ID)
+		if err != nil {
+			return nil, errors.Wrap(err, "get user")
+		}
+		if user != nil {
+			params.Profile = user.Profile
+			params.Name = user.Name
+		}
+	}
+	user, err := db.New().InsertUser(ctx, chatdb.Stdlib(), params)
+	return user, errors.Wrap(err, "insert user")
+}
+
 // getChannelHistory returns the message history for a channel. It doesn't fetch the messages from the provider,
-// but rather from the database.
-func (svc *Service) getChannelHistory(ctx context.Context, channelID db.ChannelID) ([]*db.Message, error) {
+// but rather from the database. If threadID is set, only the thread (including the message that started it)
+// is returned, otherwise only the top level messages of the channel are returned.
+func (svc *Service) getChannelHistory(ctx context.Context, channelID db.ChannelID, threadID string) ([]*db.Message, error) {
 	queries := db.New()
-	msgs, err := queries.ListMessagesInChannel(ctx, chatdb.Stdlib(), channelID)
+	var msgs []*db.Message
+	var err error
+	if threadID != "" {
+		var rows []*db.ListMessagesInThreadRow
+		rows, err = queries.ListMessagesInThread(ctx, chatdb.Stdlib(), db.ListMessagesInThreadParams{
+			ChannelID: channelID,
+			ThreadID:  threadID,
+		})
+		msgs = db.ToMessages(rows)
+	} else {
+		var rows []*db.ListMessagesInChannelRow
+		rows, err = queries.ListMessagesInChannel(ctx, chatdb.Stdlib(), channelID)
+		msgs = db.ToMessages(rows)
+	}
 	if err != nil {
 		return nil, errors.Wrap(err, "list messages by channel")
 	}
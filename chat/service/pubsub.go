@@ -27,3 +27,25 @@ var _ = pubsub.NewSubscription(
 		Handler: pubsub.MethodHandler((*Service).ProcessLLMMessage),
 	},
 )
+
+// provider-command-sub is a subscription to the provider command topic. It handles
+// bot management commands issued by users in the providers.
+//
+// This uses Encore's pubsub package, learn more: https://encore.dev/docs/primitives/pubsub
+var _ = pubsub.NewSubscription(
+	provider.CommandTopic, "provider-command-sub",
+	pubsub.SubscriptionConfig[*provider.Command]{
+		Handler: pubsub.MethodHandler((*Service).ProcessProviderCommand),
+	},
+)
+
+// provider-channel-sub is a subscription to the provider channel topic. It keeps the
+// channels in the database in sync with the providers.
+//
+// This uses Encore's pubsub package, learn more: https://encore.dev/docs/primitives/pubsub
+var _ = pubsub.NewSubscription(
+	provider.ChannelTopic, "provider-channel-sub",
+	pubsub.SubscriptionConfig[*provider.ChannelEvent]{
+		Handler: pubsub.MethodHandler((*Service).ProcessChannelEvent),
+	},
+)
@@ -0,0 +1,205 @@
+package chat
+
+import (
+	"context"
+	"database/sql"
+	"time"
+
+	"github.com/cockroachdb/errors"
+
+	botsvc "encore.app/bot"
+	"encore.app/chat/service/db"
+	"encore.dev/config"
+	"encore.dev/cron"
+	"encore.dev/rlog"
+	"encore.dev/types/uuid"
+)
+
+// forgottenUserName replaces the name of users who asked to be forgotten but whose messages are kept
+const forgottenUserName = "Forgotten User"
+
+// RetentionConfig is how many days messages are kept per provider, 0 keeps them forever
+type RetentionConfig struct {
+	Slack     config.Int
+	Discord   config.Int
+	Localchat config.Int
+}
+
+type ForgetUserRequest struct {
+	// Provider is the provider of the user, e.g. slack
+	Provider string
+	// UserID is the provider ID of the user
+	UserID string
+	// Anonymize keeps the messages of the user, but removes their name and profile. Their messages and reactions
+	// are deleted otherwise.
+	Anonymize bool
+}
+
+type ForgetUserResponse struct {
+	// Messages is the number of messages which were deleted or anonymized
+	Messages int64
+}
+
+// This cron job deletes messages which are older than the retention of their channel, and purges deleted data
+//
+// This uses Encore's cron feature, learn more: https://encore.dev/docs/primitives/cron-jobs
+var _ = cron.NewJob("purge", cron.JobConfig{
+	Title:    "Purge Expired and Deleted Data",
+	Every:    24 * cron.Hour,
+	Endpoint: Purge,
+})
+
+// Purge permanently deletes messages which are older than the retention of their channel, messages, bots and bot
+// memberships which were deleted more than PurgeDeletedAfterDays ago, and users without messages or reactions.
+//
+//encore:api private
+func (svc *Service) Purge(ctx context.Context) error {
+	q := db.New()
+	now := time.Now().UTC()
+	channels, err := q.ListChannelRetention(ctx, chatdb.Stdlib())
+	if err != nil {
+		return errors.Wrap(err, "list channel retention")
+	}
+	var expired int64
+	for _, channel := range channels {
+		before := retentionCutoff(channel.RetentionDays, channel.Provider, now)
+		if before.IsZero() {
+			continue
+		}
+		n, err := q.PurgeMessagesBefore(ctx, chatdb.Stdlib(), db.PurgeMessagesBeforeParams{
+			ChannelID: channel.ID,
+			Before:    before,
+		})
+		if err != nil {
+			return errors.Wrap(err, "purge messages")
+		}
+		expired += n
+	}
+	var deletedMessages, botChannels int64
+	var bots []string
+	if days := cfg.PurgeDeletedAfterDays(); days > 0 {
+		before := sql.NullTime{Time: now.AddDate(0, 0, -days), Valid: true}
+		deletedMessages, err = q.PurgeDeletedMessages(ctx, chatdb.Stdlib(), before)
+		if err != nil {
+			return errors.Wrap(err, "purge deleted messages")
+		}
+		botChannels, err = q.PurgeDeletedBotChannels(ctx, chatdb.Stdlib(), before)
+		if err != nil {
+			return errors.Wrap(err, "purge deleted bot channels")
+		}
+		resp, err := botsvc.PurgeDeleted(ctx, &botsvc.PurgeDeletedRequest{Before: before.Time})
+		if err != nil {
+			return errors.Wrap(err, "purge deleted bots")
+		}
+		for _, id := range resp.Bots {
+			bots = append(bots, id.String())
+		}
+	}
+	users, err := q.PurgeOrphanedUsers(ctx, chatdb.Stdlib())
+	if err != nil {
+		return errors.Wrap(err, "purge orphaned users")
+	}
+	rlog.Info("purged data",
+		"expired_messages", expired,
+		"deleted_messages", deletedMessages,
+		"bot_channels", botChannels,
+		"bots", bots,
+		"users", users)
+	return nil
+}
+
+// ForgetUser removes a provider user's messages in all channels, or anonymizes them. Messages quoted by bots or
+// other users are not affected. The user is recorded as forgotten, so their older messages are skipped or
+// anonymized when the history of a channel is backfilled.
+//
+//encore:api public method=POST path=/chat/users/forget
+func (svc *Service) ForgetUser(ctx context.Context, req *ForgetUserRequest) (*ForgetUserResponse, error) {
+	provider := db.Provider(req.Provider)
+	switch provider {
+	case db.ProviderSlack, db.ProviderDiscord, db.ProviderLocalchat:
+	default:
+		return nil, errors.Newf("unknown provider %q", req.Provider)
+	}
+	q := db.New()
+	users, err := q.ListUsersByProviderID(ctx, chatdb.Stdlib(), db.ListUsersByProviderIDParams{
+		Provider:   provider,
+		ProviderID: req.UserID,
+	})
+	if err != nil {
+		return nil, errors.Wrap(err, "list users")
+	}
+	if len(users) == 0 {
+		return nil, errors.New("user not found")
+	}
+	resp := &ForgetUserResponse{}
+	var anonymized *uuid.UUID
+	for _, user := range users {
+		if user.BotID != nil {
+			return nil, errors.New("bots can't be forgotten, delete the bot instead")
+		}
+		if req.Anonymize {
+			n, err := q.CountMessagesByAuthor(ctx, chatdb.Stdlib(), user.ID)
+			if err != nil {
+				return nil, errors.Wrap(err, "count messages")
+			}
+			// The provider ID must change too, or the user could be identified by it
+			err = q.AnonymizeUser(ctx, chatdb.Stdlib(), db.AnonymizeUserParams{
+				ProviderID: "forgotten:" + user.ID.String(),
+				Name:       forgottenUserName,
+				ID:         user.ID,
+			})
+			if err != nil {
+				return nil, errors.Wrap(err, "anonymize user")
+			}
+			resp.Messages += n
+			anonymized = &user.ID
+			continue
+		}
+		if err := q.DeleteReactionsByUser(ctx, chatdb.Stdlib(), user.ID); err != nil {
+			return nil, errors.Wrap(err, "delete reactions")
+		}
+		n, err := q.PurgeMessagesByAuthor(ctx, chatdb.Stdlib(), user.ID)
+		if err != nil {
+			return nil, errors.Wrap(err, "delete messages")
+		}
+		if err := q.DeleteUser(ctx, chatdb.Stdlib(), user.ID); err != nil {
+			return nil, errors.Wrap(err, "delete user")
+		}
+		resp.Messages += n
+	}
+	err = q.RecordForgottenUser(ctx, chatdb.Stdlib(), db.RecordForgottenUserParams{
+		Provider:   provider,
+		ProviderID: req.UserID,
+		UserID:     anonymized,
+	})
+	if err != nil {
+		return nil, errors.Wrap(err, "record forgotten user")
+	}
+	rlog.Info("forgot user", "provider", provider, "messages", resp.Messages, "anonymized", req.Anonymize)
+	return resp, nil
+}
+
+// retentionCutoff returns the time before which messages of a channel are deleted, given the retention of the
+// channel's settings. It returns the zero time if messages are kept forever.
+func retentionCutoff(days int32, provider db.Provider, now time.Time) time.Time {
+	if days == 0 {
+		days = providerRetentionDays(provider)
+	}
+	if days <= 0 {
+		return time.Time{}
+	}
+	return now.AddDate(0, 0, -int(days))
+}
+
+// providerRetentionDays returns the retention of a provider from the RetentionDays config
+func providerRetentionDays(provider db.Provider) int32 {
+	switch provider {
+	case db.ProviderSlack:
+		return int32(cfg.RetentionDays.Slack())
+	case db.ProviderDiscord:
+		return int32(cfg.RetentionDays.Discord())
+	case db.ProviderLocalchat:
+		return int32(cfg.RetentionDays.Localchat())
+	}
+	return 0
+}
@@ -0,0 +1,359 @@
+package chat
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/cockroachdb/errors"
+
+	botsvc "encore.app/bot"
+	botdb "encore.app/bot/db"
+	"encore.app/chat/service/db"
+	fns "encore.app/pkg/fns"
+	"encore.dev/cron"
+	"encore.dev/rlog"
+	"encore.dev/types/uuid"
+)
+
+// Statuses of scenario runs
+const (
+	// ScenarioRunning waits for the delay of the latest beat before playing the next one
+	ScenarioRunning = "running"
+	// ScenarioWaiting waits for a human to write in the channel, or for the timeout of the latest beat
+	ScenarioWaiting   = "waiting"
+	ScenarioPaused    = "paused"
+	ScenarioCompleted = "completed"
+	ScenarioStopped   = "stopped"
+)
+
+// defaultBeatDelay is the delay between beats which don't wait for a human if none is given
+const defaultBeatDelay = 60 * time.Second
+
+// ScenarioDefinition is a storyline the bots in a channel follow. It consists of beats which are played in order.
+type ScenarioDefinition struct {
+	Name  string
+	Beats []ScenarioBeat
+}
+
+// ScenarioBeat is a step of a scenario
+type ScenarioBeat struct {
+	// Instruction is sent to the bots when the beat is played, e.g. "Announce that the lights went out"
+	Instruction string
+	// Bots are the names of the bots which get the instruction. All bots in the channel get it if it's empty.
+	Bots []string
+	// WaitForHuman waits for a human to write in the channel before playing the next beat
+	WaitForHuman bool
+	// TimeoutSeconds plays the next beat if no human wrote within this time. 0 waits forever.
+	TimeoutSeconds int32
+	// DelaySeconds is the time until the next beat for beats which don't wait for a human, 60 seconds by default.
+	// Beats are played by a cron job which runs every minute, so shorter delays are rounded up.
+	DelaySeconds int32
+}
+
+type ListScenariosResponse struct {
+	Scenarios []*db.Scenario
+}
+
+type StartScenarioRequest struct {
+	ScenarioID uuid.UUID
+}
+
+// This cron job plays the next beat of scenarios whose delay or timeout has passed
+//
+// This uses Encore's cron feature, learn more: https://encore.dev/docs/primitives/cron-jobs
+var _ = cron.NewJob("advance-scenarios", cron.JobConfig{
+	Title:    "Advance Scenarios",
+	Every:    1 * cron.Minute,
+	Endpoint: AdvanceScenarios,
+})
+
+// CreateScenario stores a scenario which can then be started in channels.
+//
+//encore:api public method=POST path=/chat/scenarios
+func (svc *Service) CreateScenario(ctx context.Context, req *ScenarioDefinition) (*db.Scenario, error) {
+	if err := validateScenario(req); err != nil {
+		return nil, err
+	}
+	definition, err := json.Marshal(req)
+	if err != nil {
+		return nil, errors.Wrap(err, "marshal scenario")
+	}
+	scenario, err := db.New().InsertScenario(ctx, chatdb.Stdlib(), db.InsertScenarioParams{
+		Name:       req.Name,
+		Definition: definition,
+	})
+	return scenario, errors.Wrap(err, "insert scenario")
+}
+
+// ListScenarios returns all scenarios.
+//
+//encore:api public method=GET path=/chat/scenarios
+func (svc *Service) ListScenarios(ctx context.Context) (*ListScenariosResponse, error) {
+	scenarios, err := db.New().ListScenarios(ctx, chatdb.Stdlib())
+	if err != nil {
+		return nil, errors.Wrap(err, "list scenarios")
+	}
+	return &ListScenariosResponse{Scenarios: scenarios}, nil
+}
+
+// GetScenario returns a scenario by ID.
+//
+//encore:api public method=GET path=/chat/scenarios/:id
+func (svc *Service) GetScenario(ctx context.Context, id uuid.UUID) (*db.Scenario, error) {
+	scenario, err := db.New().GetScenario(ctx, chatdb.Stdlib(), id)
+	return scenario, errors.Wrap(err, "get scenario")
+}
+
+// DeleteScenario deletes a scenario. Channels which are running it play it to the end.
+//
+//encore:api public method=DELETE path=/chat/scenarios/:id
+func (svc *Service) DeleteScenario(ctx context.Context, id uuid.UUID) error {
+	n, err := db.New().DeleteScenario(ctx, chatdb.Stdlib(), id)
+	if err != nil {
+		return errors.Wrap(err, "delete scenario")
+	} else if n == 0 {
+		return errors.New("scenario not found")
+	}
+	return nil
+}
+
+// StartScenario plays the first beat of a scenario in a channel. A scenario which is already running in the
+// channel is replaced.
+//
+//encore:api public method=POST path=/chat/channels/:channelID/scenario
+func (svc *Service) StartScenario(ctx context.Context, channelID uuid.UUID, req *StartScenarioRequest) (*db.ScenarioRun, error) {
+	channel, err := svc.GetChannel(ctx, channelID)
+	if err != nil {
+		return nil, errors.Wrap(err, "get channel")
+	}
+	scenario, err := svc.GetScenario(ctx, req.ScenarioID)
+	if err != nil {
+		return nil, err
+	}
+	definition, err := parseScenario(scenario.Definition)
+	if err != nil {
+		return nil, err
+	}
+	run, err := db.New().UpsertScenarioRun(ctx, chatdb.Stdlib(), db.UpsertScenarioRunParams{
+		ChannelID:  channelID,
+		ScenarioID: req.ScenarioID,
+		Status:     ScenarioRunning,
+	})
+	if err != nil {
+		return nil, errors.Wrap(err, "upsert scenario run")
+	}
+	if err := svc.playBeat(ctx, run, definition, channel, 0); err != nil {
+		return nil, err
+	}
+	return svc.GetScenarioRun(ctx, channelID)
+}
+
+// GetScenarioRun returns the progress of the latest scenario in a channel.
+//
+//encore:api public method=GET path=/chat/channels/:channelID/scenario
+func (svc *Service) GetScenarioRun(ctx context.Context, channelID uuid.UUID) (*db.ScenarioRun, error) {
+	run, err := db.New().GetScenarioRun(ctx, chatdb.Stdlib(), channelID)
+	return run, errors.Wrap(err, "get scenario run")
+}
+
+// PauseScenario pauses the scenario in a channel. No beats are played until it's resumed.
+//
+//encore:api public method=POST path=/chat/channels/:channelID/scenario/pause
+func (svc *Service) PauseScenario(ctx context.Context, channelID uuid.UUID) error {
+	return svc.setScenarioStatus(ctx, channelID, ScenarioPaused, ScenarioRunning, ScenarioWaiting)
+}
+
+// ResumeScenario resumes a paused scenario in a channel by playing its next beat.
+//
+//encore:api public method=POST path=/chat/channels/:channelID/scenario/resume
+func (svc *Service) ResumeScenario(ctx context.Context, channelID uuid.UUID) error {
+	run, err := svc.GetScenarioRun(ctx, channelID)
+	if err != nil {
+		return err
+	}
+	if run.Status != ScenarioPaused {
+		return errors.Newf("scenario is %s, not paused", run.Status)
+	}
+	return svc.advanceScenario(ctx, run)
+}
+
+// StopScenario stops the scenario in a channel.
+//
+//encore:api public method=DELETE path=/chat/channels/:channelID/scenario
+func (svc *Service) StopScenario(ctx context.Context, channelID uuid.UUID) error {
+	return svc.setScenarioStatus(ctx, channelID, ScenarioStopped, ScenarioRunning, ScenarioWaiting, ScenarioPaused)
+}
+
+// AdvanceScenarios plays the next beat of all scenarios whose delay or timeout has passed.
+//
+//encore:api private
+func (svc *Service) AdvanceScenarios(ctx context.Context) error {
+	runs, err := db.New().ListDueScenarioRuns(ctx, chatdb.Stdlib())
+	if err != nil {
+		return errors.Wrap(err, "list due scenario runs")
+	}
+	for _, run := range runs {
+		// A failing scenario shouldn't block the others
+		if err := svc.advanceScenario(ctx, run); err != nil {
+			rlog.Error("advance scenario", "channel", run.ChannelID, "error", err)
+		}
+	}
+	return nil
+}
+
+// scenarioHumanReplied plays the next beat of a scenario which waits for a human in the channel
+func (svc *Service) scenarioHumanReplied(ctx context.Context, channelID uuid.UUID) error {
+	run, err := db.New().GetScenarioRun(ctx, chatdb.Stdlib(), channelID)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil
+	} else if err != nil {
+		return errors.Wrap(err, "get scenario run")
+	}
+	if run.Status != ScenarioWaiting {
+		return nil
+	}
+	return svc.advanceScenario(ctx, run)
+}
+
+// advanceScenario plays the beat after the latest one, or completes the scenario if it was the last beat
+func (svc *Service) advanceScenario(ctx context.Context, run *db.ScenarioRun) error {
+	channel, err := svc.GetChannel(ctx, run.ChannelID)
+	if errors.Is(err, sql.ErrNoRows) {
+		return svc.setScenarioStatus(ctx, run.ChannelID, ScenarioStopped, ScenarioRunning, ScenarioWaiting)
+	} else if err != nil {
+		return errors.Wrap(err, "get channel")
+	}
+	raw, err := db.New().GetScenarioDefinition(ctx, chatdb.Stdlib(), run.ScenarioID)
+	if err != nil {
+		return errors.Wrap(err, "get scenario definition")
+	}
+	definition, err := parseScenario(raw)
+	if err != nil {
+		return err
+	}
+	next := int(run.Beat) + 1
+	if next >= len(definition.Beats) {
+		err = db.New().UpdateScenarioRun(ctx, chatdb.Stdlib(), db.UpdateScenarioRunParams{
+			Beat:      run.Beat,
+			Status:    ScenarioCompleted,
+			ChannelID: run.ChannelID,
+		})
+		return errors.Wrap(err, "complete scenario run")
+	}
+	return svc.playBeat(ctx, run, definition, channel, next)
+}
+
+// playBeat instructs the bots of a beat and records when the next beat is due. The progress is stored first, so
+// a failing beat isn't retried every minute.
+func (svc *Service) playBeat(ctx context.Context, run *db.ScenarioRun, definition *ScenarioDefinition, channel *db.Channel, beat int) error {
+	b := definition.Beats[beat]
+	params := db.UpdateScenarioRunParams{
+		Beat:      int32(beat),
+		Status:    ScenarioRunning,
+		ChannelID: run.ChannelID,
+	}
+	switch {
+	case b.WaitForHuman && b.TimeoutSeconds > 0:
+		params.Status = ScenarioWaiting
+		params.NextAt = sql.NullTime{Time: time.Now().UTC().Add(time.Duration(b.TimeoutSeconds) * time.Second), Valid: true}
+	case b.WaitForHuman:
+		params.Status = ScenarioWaiting
+	default:
+		delay := time.Duration(b.DelaySeconds) * time.Second
+		if delay == 0 {
+			delay = defaultBeatDelay
+		}
+		params.NextAt = sql.NullTime{Time: time.Now().UTC().Add(delay), Valid: true}
+	}
+	err := db.New().UpdateScenarioRun(ctx, chatdb.Stdlib(), params)
+	if err != nil {
+		return errors.Wrap(err, "update scenario run")
+	}
+	bots, err := svc.beatBots(ctx, channel.ID, b.Bots)
+	if err != nil {
+		return err
+	}
+	if len(bots) == 0 {
+		rlog.Warn("no bots for scenario beat", "channel", channel.ID, "beat", beat)
+		return nil
+	}
+	err = svc.InstructBotInChannel(ctx, channel.ID, &InstructRequest{
+		Bots:        bots,
+		Instruction: b.Instruction,
+	})
+	return errors.Wrap(err, "instruct bots")
+}
+
+// beatBots returns the IDs of the bots in the channel with the given names, or of all bots in the channel if no
+// names are given. Bots which aren't in the channel are skipped.
+func (svc *Service) beatBots(ctx context.Context, channelID uuid.UUID, names []string) ([]uuid.UUID, error) {
+	botIDs, err := db.New().ListBotsInChannel(ctx, chatdb.Stdlib(), channelID)
+	if err != nil {
+		return nil, errors.Wrap(err, "list bots in channel")
+	}
+	if len(names) == 0 || len(botIDs) == 0 {
+		return botIDs, nil
+	}
+	resp, err := botsvc.List(ctx, &botsvc.ListBotRequest{IDs: botIDs})
+	if err != nil {
+		return nil, errors.Wrap(err, "list bots")
+	}
+	bots := fns.Filter(resp.Bots, func(b *botdb.Bot) bool {
+		return slices.ContainsFunc(names, func(name string) bool { return strings.EqualFold(b.Name, name) })
+	})
+	if len(bots) < len(names) {
+		rlog.Warn("scenario bots are missing from the channel", "channel", channelID, "bots", names)
+	}
+	return fns.Map(bots, func(b *botdb.Bot) uuid.UUID { return b.ID }), nil
+}
+
+// setScenarioStatus sets the status of the scenario in a channel if it currently has one of the from statuses
+func (svc *Service) setScenarioStatus(ctx context.Context, channelID uuid.UUID, status string, from ...string) error {
+	run, err := svc.GetScenarioRun(ctx, channelID)
+	if err != nil {
+		return err
+	}
+	if !slices.Contains(from, run.Status) {
+		return errors.Newf("scenario is %s", run.Status)
+	}
+	err = db.New().UpdateScenarioRun(ctx, chatdb.Stdlib(), db.UpdateScenarioRunParams{
+		Beat:      run.Beat,
+		Status:    status,
+		ChannelID: channelID,
+	})
+	return errors.Wrap(err, "update scenario run")
+}
+
+// parseScenario parses the definition of a scenario
+func parseScenario(raw json.RawMessage) (*ScenarioDefinition, error) {
+	var definition ScenarioDefinition
+	if err := json.Unmarshal(raw, &definition); err != nil {
+		return nil, errors.Wrap(err, "unmarshal scenario")
+	}
+	if len(definition.Beats) == 0 {
+		return nil, errors.New("scenario has no beats")
+	}
+	return &definition, nil
+}
+
+func validateScenario(s *ScenarioDefinition) error {
+	if strings.TrimSpace(s.Name) == "" {
+		return errors.New("name is required")
+	}
+	if len(s.Beats) == 0 {
+		return errors.New("a scenario needs at least one beat")
+	}
+	for i, b := range s.Beats {
+		switch {
+		case strings.TrimSpace(b.Instruction) == "":
+			return errors.Newf("beat %d: instruction is required", i+1)
+		case b.TimeoutSeconds < 0 || b.DelaySeconds < 0:
+			return errors.Newf("beat %d: timeout and delay can't be negative", i+1)
+		}
+	}
+	return nil
+}
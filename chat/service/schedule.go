@@ -0,0 +1,321 @@
+package chat
+
+import (
+	"context"
+	"math/rand"
+	"strings"
+	"text/template"
+	"time"
+
+	"github.com/cockroachdb/errors"
+	cronexpr "github.com/robfig/cron/v3"
+
+	"encore.app/chat/service/db"
+	"encore.dev/cron"
+	"encore.dev/rlog"
+	"encore.dev/types/uuid"
+)
+
+// This cron job runs the schedules which are due, including the default schedule of channels without schedules
+//
+// This uses Encore's cron feature, learn more: https://encore.dev/docs/primitives/cron-jobs
+var _ = cron.NewJob("run-schedules", cron.JobConfig{
+	Title:    "Run Conversation Schedules",
+	Every:    1 * cron.Minute,
+	Endpoint: RunSchedules,
+})
+
+// scheduleParser parses cron expressions with 5 fields, e.g. "0 9 * * MON", and descriptors like @daily
+var scheduleParser = cronexpr.NewParser(cronexpr.Minute | cronexpr.Hour | cronexpr.Dom | cronexpr.Month |
+	cronexpr.Dow | cronexpr.Descriptor)
+
+type ScheduleRequest struct {
+	Name string
+	// Cron is a cron expression with 5 fields, e.g. "0 9 * * MON" for Mondays at 9:00, or a descriptor like @daily
+	Cron string
+	// Timezone is the IANA name of the timezone of the cron expression and window, UTC if it's empty
+	Timezone string
+	// JitterSeconds delays each run by a random duration up to this many seconds
+	JitterSeconds int32
+	// WindowStart and WindowEnd are the times of day (HH:MM) outside of which runs are skipped
+	WindowStart string
+	WindowEnd   string
+	// Instruction is sent to the bots when the schedule runs. It's a Go template which can use {{.Channel}},
+	// {{.Weekday}}, {{.Date}} and {{.Time}}, e.g. "It's {{.Weekday}}, start a quiz in #{{.Channel}}".
+	Instruction string
+	Disabled    bool
+}
+
+type ListSchedulesResponse struct {
+	Schedules []*db.Schedule
+}
+
+// instructionData is the data the instruction templates of schedules are executed with
+type instructionData struct {
+	Channel string
+	Weekday string
+	Date    string
+	Time    string
+}
+
+// CreateSchedule creates a schedule which instructs the bots in a channel at the times of a cron expression.
+//
+//encore:api public method=POST path=/chat/channels/:channelID/schedules
+func (svc *Service) CreateSchedule(ctx context.Context, channelID uuid.UUID, req *ScheduleRequest) (*db.Schedule, error) {
+	if _, err := svc.GetChannel(ctx, channelID); err != nil {
+		return nil, errors.Wrap(err, "get channel")
+	}
+	next, err := validateSchedule(req)
+	if err != nil {
+		return nil, err
+	}
+	schedule, err := db.New().InsertSchedule(ctx, chatdb.Stdlib(), db.InsertScheduleParams{
+		ChannelID:     channelID,
+		Name:          req.Name,
+		Cron:          req.Cron,
+		Timezone:      req.Timezone,
+		JitterSeconds: req.JitterSeconds,
+		WindowStart:   req.WindowStart,
+		WindowEnd:     req.WindowEnd,
+		Instruction:   req.Instruction,
+		Disabled:      req.Disabled,
+		NextRun:       next,
+	})
+	return schedule, errors.Wrap(err, "insert schedule")
+}
+
+// ListSchedules returns the schedules of a channel.
+//
+//encore:api public method=GET path=/chat/channels/:channelID/schedules
+func (svc *Service) ListSchedules(ctx context.Context, channelID uuid.UUID) (*ListSchedulesResponse, error) {
+	schedules, err := db.New().ListSchedulesInChannel(ctx, chatdb.Stdlib(), channelID)
+	if err != nil {
+		return nil, errors.Wrap(err, "list schedules")
+	}
+	return &ListSchedulesResponse{Schedules: schedules}, nil
+}
+
+// GetSchedule returns a schedule by ID.
+//
+//encore:api public method=GET path=/chat/schedules/:id
+func (svc *Service) GetSchedule(ctx context.Context, id uuid.UUID) (*db.Schedule, error) {
+	schedule, err := db.New().GetSchedule(ctx, chatdb.Stdlib(), id)
+	return schedule, errors.Wrap(err, "get schedule")
+}
+
+// UpdateSchedule replaces a schedule. The next run is computed from the new cron expression.
+//
+//encore:api public method=PUT path=/chat/schedules/:id
+func (svc *Service) UpdateSchedule(ctx context.Context, id uuid.UUID, req *ScheduleRequest) (*db.Schedule, error) {
+	next, err := validateSchedule(req)
+	if err != nil {
+		return nil, err
+	}
+	schedule, err := db.New().UpdateSchedule(ctx, chatdb.Stdlib(), db.UpdateScheduleParams{
+		Name:          req.Name,
+		Cron:          req.Cron,
+		Timezone:      req.Timezone,
+		JitterSeconds: req.JitterSeconds,
+		WindowStart:   req.WindowStart,
+		WindowEnd:     req.WindowEnd,
+		Instruction:   req.Instruction,
+		Disabled:      req.Disabled,
+		NextRun:       next,
+		ID:            id,
+	})
+	return schedule, errors.Wrap(err, "update schedule")
+}
+
+// DeleteSchedule deletes a schedule.
+//
+//encore:api public method=DELETE path=/chat/schedules/:id
+func (svc *Service) DeleteSchedule(ctx context.Context, id uuid.UUID) error {
+	n, err := db.New().DeleteSchedule(ctx, chatdb.Stdlib(), id)
+	if err != nil {
+		return errors.Wrap(err, "delete schedule")
+	} else if n == 0 {
+		return errors.New("schedule not found")
+	}
+	return nil
+}
+
+// RunSchedules instructs the bots of all schedules which are due. Channels without schedules of their own
+// start conversations at the times of the DefaultSchedule config.
+//
+//encore:api private
+func (svc *Service) RunSchedules(ctx context.Context) error {
+	schedules, err := db.New().ListDueSchedules(ctx, chatdb.Stdlib())
+	if err != nil {
+		return errors.Wrap(err, "list due schedules")
+	}
+	now := time.Now().UTC()
+	for _, schedule := range schedules {
+		// A failing schedule shouldn't block the others
+		if err := svc.runSchedule(ctx, schedule, now); err != nil {
+			rlog.Error("run schedule", "schedule", schedule.ID, "error", err)
+		}
+	}
+	return svc.runDefaultSchedule(ctx, now)
+}
+
+// runSchedule instructs the bots in the channel of a schedule, unless the run is outside of the schedule's
+// window or the bots are muted by the channel settings.
+func (svc *Service) runSchedule(ctx context.Context, schedule *db.Schedule, now time.Time) error {
+	q := db.New()
+	expr, loc, err := parseSchedule(schedule.Cron, schedule.Timezone)
+	if err != nil {
+		return err
+	}
+	next := nextRun(expr, now, schedule.JitterSeconds)
+	if schedule.WindowStart != "" && !inTimeWindow(schedule.WindowStart, schedule.WindowEnd, now.In(loc)) {
+		err := q.SetScheduleNextRun(ctx, chatdb.Stdlib(), db.SetScheduleNextRunParams{NextRun: next, ID: schedule.ID})
+		return errors.Wrap(err, "set next run")
+	}
+	// The next run is stored first, so a failing run isn't retried every minute
+	err = q.SetScheduleRun(ctx, chatdb.Stdlib(), db.SetScheduleRunParams{NextRun: next, ID: schedule.ID})
+	if err != nil {
+		return errors.Wrap(err, "set schedule run")
+	}
+	channel, err := svc.GetChannel(ctx, schedule.ChannelID)
+	if err != nil {
+		return errors.Wrap(err, "get channel")
+	}
+	settings, err := svc.GetChannelSettings(ctx, channel.ID)
+	if err != nil {
+		return errors.Wrap(err, "get channel settings")
+	}
+	if reason, err := svc.botsMuted(ctx, settings, now); err != nil {
+		return errors.Wrap(err, "check channel settings")
+	} else if reason != "" {
+		rlog.Debug("skipped schedule", "schedule", schedule.ID, "reason", reason)
+		return nil
+	}
+	bots, err := q.ListBotsInChannel(ctx, chatdb.Stdlib(), channel.ID)
+	if err != nil {
+		return errors.Wrap(err, "list bots in channel")
+	}
+	if len(bots) == 0 {
+		return nil
+	}
+	instruction, err := renderInstruction(schedule.Instruction, channel, now.In(loc))
+	if err != nil {
+		return err
+	}
+	err = svc.InstructBotInChannel(ctx, channel.ID, &InstructRequest{
+		Bots:        bots,
+		Instruction: instruction,
+	})
+	return errors.Wrap(err, "instruct bots")
+}
+
+// resumeChannelSchedules enables the schedules which were disabled when their channel was archived or deleted, once
+// the channel is restored. Their next run is computed from now, so missed runs aren't made up for.
+func (svc *Service) resumeChannelSchedules(ctx context.Context, channelID uuid.UUID) error {
+	q := db.New()
+	schedules, err := q.EnableChannelSchedules(ctx, chatdb.Stdlib(), channelID)
+	if err != nil {
+		return errors.Wrap(err, "enable schedules")
+	}
+	now := time.Now().UTC()
+	for _, schedule := range schedules {
+		expr, _, err := parseSchedule(schedule.Cron, schedule.Timezone)
+		if err != nil {
+			return err
+		}
+		err = q.SetScheduleNextRun(ctx, chatdb.Stdlib(), db.SetScheduleNextRunParams{
+			NextRun: nextRun(expr, now, schedule.JitterSeconds),
+			ID:      schedule.ID,
+		})
+		if err != nil {
+			return errors.Wrap(err, "set next run")
+		}
+	}
+	return nil
+}
+
+// runDefaultSchedule initiates conversations if the DefaultSchedule config fires in the current minute
+func (svc *Service) runDefaultSchedule(ctx context.Context, now time.Time) error {
+	if cfg.DefaultSchedule() == "" {
+		return nil
+	}
+	expr, _, err := parseSchedule(cfg.DefaultSchedule(), "UTC")
+	if err != nil {
+		return errors.Wrap(err, "parse default schedule")
+	}
+	minute := now.Truncate(time.Minute)
+	if !expr.Next(minute.Add(-time.Second)).Equal(minute) {
+		return nil
+	}
+	return svc.InitiateConversation(ctx)
+}
+
+// validateSchedule validates a schedule request and returns the time of its first run
+func validateSchedule(req *ScheduleRequest) (time.Time, error) {
+	if req.Timezone == "" {
+		req.Timezone = "UTC"
+	}
+	switch {
+	case strings.TrimSpace(req.Name) == "":
+		return time.Time{}, errors.New("name is required")
+	case strings.TrimSpace(req.Instruction) == "":
+		return time.Time{}, errors.New("instruction is required")
+	case req.JitterSeconds < 0:
+		return time.Time{}, errors.New("jitter can't be negative")
+	case (req.WindowStart == "") != (req.WindowEnd == ""):
+		return time.Time{}, errors.New("the window needs both a start and an end")
+	}
+	for _, t := range []string{req.WindowStart, req.WindowEnd} {
+		if _, err := time.Parse(quietTimeLayout, t); t != "" && err != nil {
+			return time.Time{}, errors.Newf("invalid window time %q, use HH:MM", t)
+		}
+	}
+	if _, err := template.New("instruction").Parse(req.Instruction); err != nil {
+		return time.Time{}, errors.Wrap(err, "invalid instruction template")
+	}
+	expr, _, err := parseSchedule(req.Cron, req.Timezone)
+	if err != nil {
+		return time.Time{}, err
+	}
+	return nextRun(expr, time.Now().UTC(), req.JitterSeconds), nil
+}
+
+// parseSchedule parses a cron expression in a timezone
+func parseSchedule(spec, timezone string) (cronexpr.Schedule, *time.Location, error) {
+	loc, err := time.LoadLocation(timezone)
+	if err != nil {
+		return nil, nil, errors.Newf("unknown timezone %q", timezone)
+	}
+	if strings.HasPrefix(spec, "TZ=") || strings.HasPrefix(spec, "CRON_TZ=") {
+		return nil, nil, errors.New("set the timezone with the timezone field instead of the cron expression")
+	}
+	expr, err := scheduleParser.Parse("CRON_TZ=" + loc.String() + " " + spec)
+	if err != nil {
+		return nil, nil, errors.Wrapf(err, "invalid cron expression %q", spec)
+	}
+	return expr, loc, nil
+}
+
+// nextRun returns the next time of the cron expression after t, delayed by up to jitterSeconds
+func nextRun(expr cronexpr.Schedule, t time.Time, jitterSeconds int32) time.Time {
+	next := expr.Next(t)
+	if jitterSeconds > 0 {
+		next = next.Add(time.Duration(rand.Int63n(int64(jitterSeconds) * int64(time.Second))))
+	}
+	return next.UTC()
+}
+
+// renderInstruction executes the instruction template of a schedule
+func renderInstruction(instruction string, channel *db.Channel, t time.Time) (string, error) {
+	tmpl, err := template.New("instruction").Parse(instruction)
+	if err != nil {
+		return "", errors.Wrap(err, "parse instruction")
+	}
+	var b strings.Builder
+	err = tmpl.Execute(&b, instructionData{
+		Channel: channel.Name,
+		Weekday: t.Weekday().String(),
+		Date:    t.Format(time.DateOnly),
+		Time:    t.Format("15:04"),
+	})
+	return b.String(), errors.Wrap(err, "execute instruction")
+}
@@ -0,0 +1,72 @@
+package chat
+
+import (
+	"context"
+	"database/sql"
+	"strings"
+	"time"
+
+	"github.com/cockroachdb/errors"
+
+	"encore.app/chat/service/db"
+	"encore.dev/types/uuid"
+)
+
+const (
+	defaultSearchResults = 25
+	maxSearchResults     = 100
+)
+
+type SearchRequest struct {
+	// Q is the search query. It supports web search syntax, e.g. `"exact phrase"`, `pizza or pasta` and
+	// `pizza -pineapple`.
+	Q string `query:"q"`
+	// Channel is the ID of the channel to search in, all channels are searched if it's empty
+	Channel string `query:"channel"`
+	// Author is the name of the user or bot who wrote the messages
+	Author string `query:"author"`
+	// From and To limit the results to messages written in this time range
+	From time.Time `query:"from"`
+	To   time.Time `query:"to"`
+	// Limit is the maximum number of results, 25 by default and at most 100
+	Limit int32 `query:"limit"`
+}
+
+type SearchResponse struct {
+	Results []*db.SearchMessagesRow
+}
+
+// Search searches the message history of all channels. Results are ordered by relevance, and their snippets
+// highlight the matching words in **bold**.
+//
+//encore:api public method=GET path=/chat/search
+func (svc *Service) Search(ctx context.Context, req *SearchRequest) (*SearchResponse, error) {
+	if strings.TrimSpace(req.Q) == "" {
+		return nil, errors.New("query is required")
+	}
+	params := db.SearchMessagesParams{
+		Query:      req.Q,
+		Author:     sql.NullString{String: req.Author, Valid: req.Author != ""},
+		FromTime:   sql.NullTime{Time: req.From.UTC(), Valid: !req.From.IsZero()},
+		ToTime:     sql.NullTime{Time: req.To.UTC(), Valid: !req.To.IsZero()},
+		MaxResults: req.Limit,
+	}
+	if req.Channel != "" {
+		channelID, err := uuid.FromString(req.Channel)
+		if err != nil {
+			return nil, errors.Wrap(err, "invalid channel ID")
+		}
+		params.ChannelID = &channelID
+	}
+	switch {
+	case params.MaxResults <= 0:
+		params.MaxResults = defaultSearchResults
+	case params.MaxResults > maxSearchResults:
+		params.MaxResults = maxSearchResults
+	}
+	results, err := db.New().SearchMessages(ctx, chatdb.Stdlib(), params)
+	if err != nil {
+		return nil, errors.Wrap(err, "search messages")
+	}
+	return &SearchResponse{Results: results}, nil
+}
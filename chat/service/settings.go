@@ -0,0 +1,215 @@
+package chat
+
+import (
+	"context"
+	"database/sql"
+	"math/rand"
+	"time"
+
+	"github.com/cockroachdb/errors"
+
+	botdb "encore.app/bot/db"
+	"encore.app/chat/service/db"
+	"encore.dev/rlog"
+	"encore.dev/types/uuid"
+)
+
+// quietTimeLayout is the layout of the start and end of quiet hours and schedule windows
+const quietTimeLayout = "15:04"
+
+// UpdateChannelSettingsRequest updates the settings of a channel. Settings which aren't set keep their current
+// value.
+type UpdateChannelSettingsRequest struct {
+	// ReplyProbability is the chance that bots reply to a message, from 0 to 1
+	ReplyProbability *float64
+	// CooldownSeconds is the minimum time between the latest bot message and the next reply
+	CooldownSeconds *int32
+	// MaxMessagesPerHour limits the number of bot messages per hour, 0 is unlimited
+	MaxMessagesPerHour *int32
+	// QuietStart and QuietEnd are the times of day (HH:MM) between which bots don't reply. Quiet hours may span
+	// midnight, e.g. 22:00 to 07:00. Set both to an empty string to disable them.
+	QuietStart *string
+	QuietEnd   *string
+	// Timezone is the IANA name of the timezone of the quiet hours, e.g. Europe/Stockholm
+	Timezone *string
+	// MentionsOnly makes bots only reply to messages which mention them by name
+	MentionsOnly *bool
+	// RetentionDays is how many days messages in the channel are kept, 0 uses the RetentionDays config of the
+	// channel's provider
+	RetentionDays *int32
+}
+
+// GetChannelSettings returns the settings of a channel. Channels which were never configured return the defaults.
+//
+//encore:api public method=GET path=/chat/channels/:channelID/settings
+func (svc *Service) GetChannelSettings(ctx context.Context, channelID uuid.UUID) (*db.ChannelSetting, error) {
+	settings, err := db.New().GetChannelSettings(ctx, chatdb.Stdlib(), channelID)
+	if errors.Is(err, sql.ErrNoRows) {
+		return &db.ChannelSetting{
+			ChannelID:        channelID,
+			ReplyProbability: 1,
+			Timezone:         "UTC",
+		}, nil
+	}
+	return settings, errors.Wrap(err, "get channel settings")
+}
+
+// UpdateChannelSettings updates the settings which control how often bots reply in a channel.
+//
+//encore:api public method=POST path=/chat/channels/:channelID/settings
+func (svc *Service) UpdateChannelSettings(ctx context.Context, channelID uuid.UUID, req *UpdateChannelSettingsRequest) (*db.ChannelSetting, error) {
+	if _, err := svc.GetChannel(ctx, channelID); err != nil {
+		return nil, errors.Wrap(err, "get channel")
+	}
+	settings, err := svc.GetChannelSettings(ctx, channelID)
+	if err != nil {
+		return nil, err
+	}
+	setIfNotNil(&settings.ReplyProbability, req.ReplyProbability)
+	setIfNotNil(&settings.CooldownSeconds, req.CooldownSeconds)
+	setIfNotNil(&settings.MaxMessagesPerHour, req.MaxMessagesPerHour)
+	setIfNotNil(&settings.QuietStart, req.QuietStart)
+	setIfNotNil(&settings.QuietEnd, req.QuietEnd)
+	setIfNotNil(&settings.Timezone, req.Timezone)
+	setIfNotNil(&settings.MentionsOnly, req.MentionsOnly)
+	setIfNotNil(&settings.RetentionDays, req.RetentionDays)
+	if err := validateSettings(settings); err != nil {
+		return nil, err
+	}
+	settings, err = db.New().UpsertChannelSettings(ctx, chatdb.Stdlib(), db.UpsertChannelSettingsParams{
+		ChannelID:          channelID,
+		ReplyProbability:   settings.ReplyProbability,
+		CooldownSeconds:    settings.CooldownSeconds,
+		MaxMessagesPerHour: settings.MaxMessagesPerHour,
+		QuietStart:         settings.QuietStart,
+		QuietEnd:           settings.QuietEnd,
+		Timezone:           settings.Timezone,
+		MentionsOnly:       settings.MentionsOnly,
+		RetentionDays:      settings.RetentionDays,
+	})
+	return settings, errors.Wrap(err, "upsert channel settings")
+}
+
+func setIfNotNil[T any](dst *T, src *T) {
+	if src != nil {
+		*dst = *src
+	}
+}
+
+func validateSettings(s *db.ChannelSetting) error {
+	switch {
+	case s.ReplyProbability < 0 || s.ReplyProbability > 1:
+		return errors.New("reply probability must be between 0 and 1")
+	case s.CooldownSeconds < 0:
+		return errors.New("cooldown can't be negative")
+	case s.MaxMessagesPerHour < 0:
+		return errors.New("max messages per hour can't be negative")
+	case (s.QuietStart == "") != (s.QuietEnd == ""):
+		return errors.New("quiet hours need both a start and an end")
+	case s.RetentionDays < 0:
+		return errors.New("retention can't be negative")
+	}
+	if _, err := time.LoadLocation(s.Timezone); err != nil {
+		return errors.Newf("unknown timezone %q", s.Timezone)
+	}
+	for _, t := range []string{s.QuietStart, s.QuietEnd} {
+		if _, err := time.Parse(quietTimeLayout, t); t != "" && err != nil {
+			return errors.Newf("invalid quiet hours time %q, use HH:MM", t)
+		}
+	}
+	return nil
+}
+
+// inQuietHours returns true if t is within the quiet hours of the channel
+func inQuietHours(s *db.ChannelSetting, t time.Time) bool {
+	if s.QuietStart == "" {
+		return false
+	}
+	loc, err := time.LoadLocation(s.Timezone)
+	if err != nil {
+		rlog.Warn("invalid channel timezone", "channel", s.ChannelID, "timezone", s.Timezone)
+		loc = time.UTC
+	}
+	return inTimeWindow(s.QuietStart, s.QuietEnd, t.In(loc))
+}
+
+// inTimeWindow returns true if the time of day of t is between start and end (HH:MM). Windows may span midnight,
+// e.g. 22:00 to 07:00. Invalid windows contain no time.
+func inTimeWindow(start, end string, t time.Time) bool {
+	from, errStart := time.Parse(quietTimeLayout, start)
+	to, errEnd := time.Parse(quietTimeLayout, end)
+	if errStart != nil || errEnd != nil {
+		return false
+	}
+	now := t.Hour()*60 + t.Minute()
+	fromMin, toMin := from.Hour()*60+from.Minute(), to.Hour()*60+to.Minute()
+	if fromMin <= toMin {
+		return now >= fromMin && now < toMin
+	}
+	return now >= fromMin || now < toMin
+}
+
+// botsMuted returns the reason why bots shouldn't post in a channel right now because of its quiet hours,
+// cooldown or hourly limit, or an empty string if they may post.
+func (svc *Service) botsMuted(ctx context.Context, s *db.ChannelSetting, now time.Time) (string, error) {
+	if inQuietHours(s, now) {
+		return "quiet hours", nil
+	}
+	q := db.New()
+	if s.CooldownSeconds > 0 {
+		latest, err := q.LatestBotMessageInChannel(ctx, chatdb.Stdlib(), s.ChannelID)
+		if err != nil && !errors.Is(err, sql.ErrNoRows) {
+			return "", errors.Wrap(err, "latest bot message in channel")
+		}
+		if err == nil && now.Sub(latest.Timestamp) < time.Duration(s.CooldownSeconds)*time.Second {
+			return "cooldown", nil
+		}
+	}
+	if s.MaxMessagesPerHour > 0 {
+		count, err := q.CountBotMessagesSince(ctx, chatdb.Stdlib(), db.CountBotMessagesSinceParams{
+			ChannelID: s.ChannelID,
+			Since:     now.Add(-time.Hour),
+		})
+		if err != nil {
+			return "", errors.Wrap(err, "count bot messages")
+		}
+		if count >= int64(s.MaxMessagesPerHour) {
+			return "hourly limit", nil
+		}
+	}
+	return "", nil
+}
+
+// botsToReply returns the bots which reply to a message according to the settings of the channel. It returns
+// no bots if they should stay silent. Messages addressed to bots are only answered by those bots and skip the
+// reply probability.
+func (svc *Service) botsToReply(ctx context.Context, channel *db.Channel, bots []*botdb.Bot, msg *db.Message) ([]*botdb.Bot, error) {
+	settings, err := svc.GetChannelSettings(ctx, channel.ID)
+	if err != nil {
+		return nil, err
+	}
+	reason, err := svc.botsMuted(ctx, settings, time.Now().UTC())
+	if err != nil {
+		return nil, err
+	} else if reason != "" {
+		rlog.Debug("bots are muted", "channel", channel.ID, "reason", reason)
+		return nil, nil
+	}
+	mentions, err := svc.newMentionResolver(ctx, channel.Provider, bots)
+	if err != nil {
+		return nil, errors.Wrap(err, "create mention resolver")
+	}
+	// Messages addressed to specific bots are only sent to them, which saves tokens and keeps the other bots
+	// from chiming in
+	addressed := addressedBots(mentions.toNames(msg.Content), bots)
+	switch {
+	case len(addressed) > 0:
+		return addressed, nil
+	case settings.MentionsOnly:
+		return nil, nil
+	case rand.Float64() >= settings.ReplyProbability:
+		rlog.Debug("bots skipped a message", "channel", channel.ID, "probability", settings.ReplyProbability)
+		return nil, nil
+	}
+	return bots, nil
+}
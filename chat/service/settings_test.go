@@ -0,0 +1,40 @@
+package chat
+
+import (
+	"testing"
+	"time"
+)
+
+func TestInTimeWindow(t *testing.T) {
+	at := func(clock string) time.Time {
+		tm, err := time.Parse(quietTimeLayout, clock)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return tm
+	}
+	tests := []struct {
+		start, end, now string
+		want            bool
+	}{
+		{start: "09:00", end: "17:00", now: "08:59", want: false},
+		{start: "09:00", end: "17:00", now: "09:00", want: true},
+		{start: "09:00", end: "17:00", now: "16:59", want: true},
+		{start: "09:00", end: "17:00", now: "17:00", want: false},
+		{start: "22:00", end: "07:00", now: "21:59", want: false},
+		{start: "22:00", end: "07:00", now: "22:00", want: true},
+		{start: "22:00", end: "07:00", now: "23:59", want: true},
+		{start: "22:00", end: "07:00", now: "00:00", want: true},
+		{start: "22:00", end: "07:00", now: "06:59", want: true},
+		{start: "22:00", end: "07:00", now: "07:00", want: false},
+		{start: "12:00", end: "12:00", now: "12:00", want: false},
+		{start: "00:00", end: "23:59", now: "23:59", want: false},
+		{start: "", end: "07:00", now: "03:00", want: false},
+		{start: "22:00", end: "7pm", now: "23:00", want: false},
+	}
+	for _, tt := range tests {
+		if got := inTimeWindow(tt.start, tt.end, at(tt.now)); got != tt.want {
+			t.Errorf("inTimeWindow(%q, %q, %s) = %v, want %v", tt.start, tt.end, tt.now, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,245 @@
+package chat
+
+import (
+	"context"
+	"time"
+
+	"github.com/cockroachdb/errors"
+
+	botsvc "encore.app/bot"
+	"encore.app/chat/service/db"
+	"encore.dev/cron"
+	"encore.dev/metrics"
+	"encore.dev/rlog"
+	"encore.dev/types/uuid"
+)
+
+const (
+	defaultStatsDays = 7
+	maxStatsDays     = 90
+)
+
+// StatsRequest selects the period of the statistics
+type StatsRequest struct {
+	// Days is the number of days to compute the statistics for, 7 by default and at most 90
+	Days int `query:"days"`
+}
+
+// ChannelStats are the statistics of a channel. A human message counts as replied to if a bot writes in the same
+// thread before the next human message, and the response time is the time until that first bot message.
+type ChannelStats struct {
+	ChannelID     uuid.UUID
+	Since         time.Time
+	Days          []*db.ChannelMessagesPerDayRow
+	HumanMessages int64
+	BotMessages   int64
+	// BotMessageShare is the share of all messages written by bots, from 0 to 1
+	BotMessageShare float64
+	ActiveHumans    int64
+	// ReplyShare is the share of human messages which got a bot reply, from 0 to 1
+	ReplyShare         float64
+	AvgResponseSeconds float64
+}
+
+// BotStats are the statistics of a bot across the channels it's in. Only human messages in these channels and
+// the bot's own replies count.
+type BotStats struct {
+	BotID              uuid.UUID
+	Since              time.Time
+	Days               []*db.BotMessagesPerDayRow
+	Messages           int64
+	HumanMessages      int64
+	ReplyShare         float64
+	AvgResponseSeconds float64
+}
+
+type channelLabels struct {
+	Provider string
+	Channel  string
+}
+
+type botLabels struct {
+	Bot string
+}
+
+// These metrics track the statistics of the past week of each channel with bots and of each bot in them.
+// They're updated by the update-stats-metrics cron job.
+//
+// This uses Encore's metrics feature, learn more: https://encore.dev/docs/observability/metrics
+var (
+	ChannelActiveHumans    = metrics.NewGaugeGroup[channelLabels, int64]("channel_active_humans", metrics.GaugeConfig{})
+	ChannelBotMessageShare = metrics.NewGaugeGroup[channelLabels, float64]("channel_bot_message_share", metrics.GaugeConfig{})
+	ChannelReplyShare      = metrics.NewGaugeGroup[channelLabels, float64]("channel_reply_share", metrics.GaugeConfig{})
+	ChannelResponseSeconds = metrics.NewGaugeGroup[channelLabels, float64]("channel_response_seconds", metrics.GaugeConfig{})
+	BotMessageCount        = metrics.NewGaugeGroup[botLabels, int64]("bot_messages", metrics.GaugeConfig{})
+	BotReplyShare          = metrics.NewGaugeGroup[botLabels, float64]("bot_reply_share", metrics.GaugeConfig{})
+	BotResponseSeconds     = metrics.NewGaugeGroup[botLabels, float64]("bot_response_seconds", metrics.GaugeConfig{})
+)
+
+// This cron job updates the channel and bot metrics
+//
+// This uses Encore's cron feature, learn more: https://encore.dev/docs/primitives/cron-jobs
+var _ = cron.NewJob("update-stats-metrics", cron.JobConfig{
+	Title:    "Update Channel and Bot Metrics",
+	Every:    1 * cron.Hour,
+	Endpoint: UpdateStatsMetrics,
+})
+
+// GetChannelStats returns the statistics of a channel, e.g. how many humans are active and how often bots reply.
+//
+//encore:api public method=GET path=/chat/channels/:channelID/stats
+func (svc *Service) GetChannelStats(ctx context.Context, channelID uuid.UUID, req *StatsRequest) (*ChannelStats, error) {
+	since, err := statsSince(req.Days)
+	if err != nil {
+		return nil, err
+	}
+	return svc.channelStats(ctx, channelID, since)
+}
+
+// GetBotStats returns the statistics of a bot, e.g. how many messages it wrote and how often it replied to humans.
+//
+//encore:api public method=GET path=/chat/bots/:botID/stats
+func (svc *Service) GetBotStats(ctx context.Context, botID uuid.UUID, req *StatsRequest) (*BotStats, error) {
+	since, err := statsSince(req.Days)
+	if err != nil {
+		return nil, err
+	}
+	return svc.botStats(ctx, botID, since)
+}
+
+// UpdateStatsMetrics updates the metrics of all channels with bots and of the bots in them.
+//
+//encore:api private
+func (svc *Service) UpdateStatsMetrics(ctx context.Context) error {
+	since := time.Now().UTC().Add(-defaultStatsDays * 24 * time.Hour)
+	q := db.New()
+	channels, err := q.ListChannelsWithBots(ctx, chatdb.Stdlib())
+	if err != nil {
+		return errors.Wrap(err, "list channels with bots")
+	}
+	var botIDs []uuid.UUID
+	for _, channel := range channels {
+		stats, err := svc.channelStats(ctx, channel.ID, since)
+		if err != nil {
+			rlog.Error("channel stats", "channel", channel.ID, "error", err)
+			continue
+		}
+		labels := channelLabels{Provider: string(channel.Provider), Channel: channel.Name}
+		ChannelActiveHumans.With(labels).Set(stats.ActiveHumans)
+		ChannelBotMessageShare.With(labels).Set(stats.BotMessageShare)
+		ChannelReplyShare.With(labels).Set(stats.ReplyShare)
+		ChannelResponseSeconds.With(labels).Set(stats.AvgResponseSeconds)
+		ids, err := q.ListBotsInChannel(ctx, chatdb.Stdlib(), channel.ID)
+		if err != nil {
+			return errors.Wrap(err, "list bots in channel")
+		}
+		botIDs = append(botIDs, ids...)
+	}
+	if len(botIDs) == 0 {
+		return nil
+	}
+	bots, err := botsvc.List(ctx, &botsvc.ListBotRequest{IDs: botIDs})
+	if err != nil {
+		return errors.Wrap(err, "list bots")
+	}
+	for _, b := range bots.Bots {
+		stats, err := svc.botStats(ctx, b.ID, since)
+		if err != nil {
+			rlog.Error("bot stats", "bot", b.ID, "error", err)
+			continue
+		}
+		labels := botLabels{Bot: b.Name}
+		BotMessageCount.With(labels).Set(stats.Messages)
+		BotReplyShare.With(labels).Set(stats.ReplyShare)
+		BotResponseSeconds.With(labels).Set(stats.AvgResponseSeconds)
+	}
+	return nil
+}
+
+func (svc *Service) channelStats(ctx context.Context, channelID uuid.UUID, since time.Time) (*ChannelStats, error) {
+	q := db.New()
+	days, err := q.ChannelMessagesPerDay(ctx, chatdb.Stdlib(), db.ChannelMessagesPerDayParams{
+		ChannelID: channelID,
+		Since:     since,
+	})
+	if err != nil {
+		return nil, errors.Wrap(err, "messages per day")
+	}
+	active, err := q.CountActiveHumansInChannel(ctx, chatdb.Stdlib(), db.CountActiveHumansInChannelParams{
+		ChannelID: channelID,
+		Since:     since,
+	})
+	if err != nil {
+		return nil, errors.Wrap(err, "count active humans")
+	}
+	replies, err := q.ChannelReplyStats(ctx, chatdb.Stdlib(), db.ChannelReplyStatsParams{
+		ChannelID: channelID,
+		Since:     since,
+	})
+	if err != nil {
+		return nil, errors.Wrap(err, "reply stats")
+	}
+	stats := &ChannelStats{
+		ChannelID:          channelID,
+		Since:              since,
+		Days:               days,
+		ActiveHumans:       active,
+		ReplyShare:         share(replies.Replied, replies.HumanMessages),
+		AvgResponseSeconds: replies.AvgResponseSeconds,
+	}
+	for _, d := range days {
+		stats.HumanMessages += d.HumanMessages
+		stats.BotMessages += d.BotMessages
+	}
+	stats.BotMessageShare = share(stats.BotMessages, stats.HumanMessages+stats.BotMessages)
+	return stats, nil
+}
+
+func (svc *Service) botStats(ctx context.Context, botID uuid.UUID, since time.Time) (*BotStats, error) {
+	q := db.New()
+	days, err := q.BotMessagesPerDay(ctx, chatdb.Stdlib(), db.BotMessagesPerDayParams{
+		BotID: botID,
+		Since: since,
+	})
+	if err != nil {
+		return nil, errors.Wrap(err, "messages per day")
+	}
+	replies, err := q.BotReplyStats(ctx, chatdb.Stdlib(), db.BotReplyStatsParams{
+		BotID: botID,
+		Since: since,
+	})
+	if err != nil {
+		return nil, errors.Wrap(err, "reply stats")
+	}
+	stats := &BotStats{
+		BotID:              botID,
+		Since:              since,
+		Days:               days,
+		HumanMessages:      replies.HumanMessages,
+		ReplyShare:         share(replies.Replied, replies.HumanMessages),
+		AvgResponseSeconds: replies.AvgResponseSeconds,
+	}
+	for _, d := range days {
+		stats.Messages += d.Messages
+	}
+	return stats, nil
+}
+
+// statsSince returns the start of a statistics period of a number of days, which ends now
+func statsSince(days int) (time.Time, error) {
+	switch {
+	case days == 0:
+		days = defaultStatsDays
+	case days < 0 || days > maxStatsDays:
+		return time.Time{}, errors.Newf("days must be between 1 and %d", maxStatsDays)
+	}
+	return time.Now().UTC().AddDate(0, 0, -days), nil
+}
+
+// share returns n as a share of total, or 0 if total is 0
+func share(n, total int64) float64 {
+	if total == 0 {
+		return 0
+	}
+	return float64(n) / float64(total)
+}
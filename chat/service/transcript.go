@@ -0,0 +1,436 @@
+package chat
+
+import (
+	"bytes"
+	"context"
+	"database/sql"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"html/template"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/cockroachdb/errors"
+
+	botsvc "encore.app/bot"
+	botdb "encore.app/bot/db"
+	"encore.app/chat/service/db"
+	fns "encore.app/pkg/fns"
+	"encore.dev"
+	"encore.dev/rlog"
+	"encore.dev/types/uuid"
+)
+
+// importedUserPrefix prefixes the provider IDs of users created for the authors of imported messages
+const importedUserPrefix = "import:"
+
+// Transcript is the conversation of a channel, as exported in the JSON format
+type Transcript struct {
+	Channel  string
+	Provider string
+	Exported time.Time
+	Bots     []*TranscriptBot
+	Messages []*TranscriptMessage
+}
+
+type TranscriptBot struct {
+	Name string
+	// Avatar is the avatar of the bot as a data URI, it's empty if the bot has no avatar
+	Avatar string
+}
+
+type TranscriptMessage struct {
+	Author    string
+	Bot       bool
+	Timestamp time.Time
+	Content   string
+}
+
+type ImportTranscriptRequest struct {
+	// Messages are messages in the format of exported transcripts
+	Messages []*TranscriptMessage
+	// SlackMessages are messages from a Slack export, i.e. the contents of the daily JSON files of a channel
+	SlackMessages []*SlackExportMessage
+}
+
+// SlackExportMessage is a message in a Slack export
+type SlackExportMessage struct {
+	Type        string            `json:"type"`
+	Subtype     string            `json:"subtype"`
+	User        string            `json:"user"`
+	Username    string            `json:"username"`
+	Text        string            `json:"text"`
+	Ts          string            `json:"ts"`
+	UserProfile *SlackUserProfile `json:"user_profile"`
+}
+
+type SlackUserProfile struct {
+	RealName    string `json:"real_name"`
+	DisplayName string `json:"display_name"`
+}
+
+type ImportTranscriptResponse struct {
+	Imported int
+	// Skipped is the number of messages which were already in the channel
+	Skipped int
+}
+
+// ExportTranscript exports the conversation of a channel. The format query parameter selects the format: json
+// (default), markdown or html. Authors are resolved to user and bot names, and bot avatars are embedded.
+//
+//encore:api public raw method=GET path=/chat/channels/:channelID/transcript
+func (svc *Service) ExportTranscript(w http.ResponseWriter, req *http.Request) {
+	channelID, err := uuid.FromString(encore.CurrentRequest().PathParams.Get("channelID"))
+	if err != nil {
+		http.Error(w, "invalid channel ID", http.StatusBadRequest)
+		return
+	}
+	channel, err := svc.GetChannel(req.Context(), channelID)
+	if err != nil {
+		http.Error(w, "channel not found", http.StatusNotFound)
+		return
+	}
+	transcript, err := svc.transcript(req.Context(), channel)
+	if err != nil {
+		rlog.Error("export transcript", "channel", channelID, "error", err)
+		http.Error(w, "failed to export transcript", http.StatusInternalServerError)
+		return
+	}
+	var buf bytes.Buffer
+	var contentType, ext string
+	switch format := req.URL.Query().Get("format"); format {
+	case "", "json":
+		contentType, ext = "application/json", "json"
+		enc := json.NewEncoder(&buf)
+		enc.SetIndent("", "  ")
+		err = enc.Encode(transcript)
+	case "markdown", "md":
+		contentType, ext = "text/markdown; charset=utf-8", "md"
+		writeMarkdownTranscript(&buf, transcript)
+	case "html":
+		contentType, ext = "text/html; charset=utf-8", "html"
+		err = writeHTMLTranscript(&buf, transcript)
+	default:
+		http.Error(w, fmt.Sprintf("unknown format %q, use json, markdown or html", format), http.StatusBadRequest)
+		return
+	}
+	if err != nil {
+		rlog.Error("render transcript", "channel", channelID, "error", err)
+		http.Error(w, "failed to export transcript", http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", contentType)
+	w.Header().Set("Content-Disposition", fmt.Sprintf("attachment; filename=%q", channel.Name+"."+ext))
+	_, _ = w.Write(buf.Bytes())
+}
+
+// ImportTranscript imports messages into a channel, e.g. to seed a local chat channel from a Slack export. Authors
+// with the name of a bot in the channel become that bot, all others become users of the channel's provider.
+// Imported messages keep their timestamps, so bots only see the ones from the last days of the channel history.
+// Messages which were already imported are skipped.
+//
+//encore:api public method=POST path=/chat/channels/:channelID/transcript
+func (svc *Service) ImportTranscript(ctx context.Context, channelID uuid.UUID, req *ImportTranscriptRequest) (*ImportTranscriptResponse, error) {
+	channel, err := svc.GetChannel(ctx, channelID)
+	if err != nil {
+		return nil, errors.Wrap(err, "get channel")
+	}
+	msgs := append(req.Messages, fromSlackExport(req.SlackMessages)...)
+	for i, msg := range msgs {
+		if strings.TrimSpace(msg.Author) == "" || msg.Timestamp.IsZero() {
+			return nil, errors.Newf("message %d: author and timestamp are required", i+1)
+		}
+	}
+	q := db.New()
+	botIDs, err := q.ListBotsInChannel(ctx, chatdb.Stdlib(), channelID)
+	if err != nil {
+		return nil, errors.Wrap(err, "list bots in channel")
+	}
+	bots, err := botsvc.List(ctx, &botsvc.ListBotRequest{IDs: botIDs})
+	if err != nil {
+		return nil, errors.Wrap(err, "list bots")
+	}
+	botsByName := fns.ToMap(bots.Bots, func(b *botdb.Bot) string { return strings.ToLower(b.Name) })
+	authors := map[string]*db.User{}
+	resp := &ImportTranscriptResponse{}
+	for _, msg := range msgs {
+		author, ok := authors[msg.Author]
+		if !ok {
+			author, err = svc.importAuthor(ctx, channel, msg.Author, botsByName[strings.ToLower(msg.Author)])
+			if err != nil {
+				return nil, err
+			}
+			authors[msg.Author] = author
+		}
+		// Imported messages have no provider ID, so edits and deletions never match them. The one-off repair of Slack
+		// message IDs skips the messages of import: users too.
+		_, err := q.InsertMessage(ctx, chatdb.Stdlib(), db.InsertMessageParams{
+			ChannelID: channelID,
+			AuthorID:  author.ID,
+			Content:   msg.Content,
+			Timestamp: msg.Timestamp.UTC(),
+		})
+		if errors.Is(err, sql.ErrNoRows) {
+			resp.Skipped++
+			continue
+		} else if err != nil {
+			return nil, errors.Wrap(err, "insert message")
+		}
+		resp.Imported++
+	}
+	return resp, nil
+}
+
+// importAuthor returns the user for the author of an imported message. It's the bot's user if the author is a
+// bot, and a user which is created on first use otherwise.
+func (svc *Service) importAuthor(ctx context.Context, channel *db.Channel, name string, b *botdb.Bot) (*db.User, error) {
+	q := db.New()
+	var user *db.User
+	var err error
+	if b != nil {
+		user, err = q.GetBotUser(ctx, chatdb.Stdlib(), db.GetBotUserParams{
+			Provider: channel.Provider,
+			BotID:    &b.ID,
+		})
+	} else {
+		user, err = q.GetUserByProviderID(ctx, chatdb.Stdlib(), db.GetUserByProviderIDParams{
+			Provider:   channel.Provider,
+			ProviderID: importedUserPrefix + name,
+		})
+	}
+	if err == nil {
+		return user, nil
+	} else if !errors.Is(err, sql.ErrNoRows) {
+		return nil, errors.Wrap(err, "get user")
+	}
+	params := db.InsertUserParams{
+		Provider:   channel.Provider,
+		ProviderID: importedUserPrefix + name,
+		Name:       name,
+	}
+	if b != nil {
+		params.ProviderID = importedUserPrefix + b.ID.String()
+		params.Name = b.Name
+		params.BotID = &b.ID
+	}
+	user, err = q.InsertUser(ctx, chatdb.Stdlib(), params)
+	return user, errors.Wrap(err, "insert user")
+}
+
+// transcript returns the conversation of a channel. Admin instructions aren't part of the conversation and are
+// left out.
+func (svc *Service) transcript(ctx context.Context, channel *db.Channel) (*Transcript, error) {
+	q := db.New()
+	msgs, err := q.ListChannelTranscript(ctx, chatdb.Stdlib(), channel.ID)
+	if err != nil {
+		return nil, errors.Wrap(err, "list messages")
+	}
+	users, err := q.ListUsersInChannel(ctx, chatdb.Stdlib(), channel.ID)
+	if err != nil {
+		return nil, errors.Wrap(err, "list users in channel")
+	}
+	usersByID := fns.ToMap(users, func(u *db.User) uuid.UUID { return u.ID })
+	botIDs := fns.Unique(fns.Map(fns.Filter(users, func(u *db.User) bool { return u.BotID != nil }),
+		func(u *db.User) uuid.UUID { return *u.BotID }))
+	bots, err := botsvc.List(ctx, &botsvc.ListBotRequest{IDs: botIDs})
+	if err != nil {
+		return nil, errors.Wrap(err, "list bots")
+	}
+	botsByID := fns.ToMap(bots.Bots, func(b *botdb.Bot) uuid.UUID { return b.ID })
+	mentions, err := svc.newMentionResolver(ctx, channel.Provider, bots.Bots)
+	if err != nil {
+		return nil, errors.Wrap(err, "create mention resolver")
+	}
+	transcript := &Transcript{
+		Channel:  channel.Name,
+		Provider: string(channel.Provider),
+		Exported: time.Now().UTC(),
+		Messages: []*TranscriptMessage{},
+	}
+	for _, b := range bots.Bots {
+		transcript.Bots = append(transcript.Bots, &TranscriptBot{Name: b.Name, Avatar: avatarDataURI(ctx, b)})
+	}
+	for _, msg := range mentions.resolveMentions(db.ToMessages(msgs)) {
+		if msg.AuthorID == db.Admin.ID {
+			continue
+		}
+		tm := &TranscriptMessage{Timestamp: msg.Timestamp, Content: msg.Content}
+		if author, ok := usersByID[msg.AuthorID]; ok {
+			tm.Author = author.Name
+			if author.BotID != nil {
+				tm.Bot = true
+				// Users of bots may have a provider-specific name, the bot's name is the one people know
+				if b, ok := botsByID[*author.BotID]; ok {
+					tm.Author = b.Name
+				}
+			}
+		}
+		transcript.Messages = append(transcript.Messages, tm)
+	}
+	return transcript, nil
+}
+
+// avatarDataURI returns the avatar of a bot as a data URI, or an empty string if it doesn't have one
+func avatarDataURI(ctx context.Context, b *botdb.Bot) string {
+	avatar, err := botsvc.AvatarBlob(ctx, b.ID)
+	if err != nil || avatar == nil || len(avatar.Avatar) == 0 {
+		return ""
+	}
+	return "data:" + http.DetectContentType(avatar.Avatar) + ";base64," + base64.StdEncoding.EncodeToString(avatar.Avatar)
+}
+
+func writeMarkdownTranscript(buf *bytes.Buffer, t *Transcript) {
+	fmt.Fprintf(buf, "# #%s\n\nExported from %s on %s.\n\n", t.Channel, t.Provider, t.Exported.Format(time.DateOnly))
+	if len(t.Bots) > 0 {
+		buf.WriteString("## Bots\n\n")
+		for _, b := range t.Bots {
+			if b.Avatar != "" {
+				fmt.Fprintf(buf, "- ![%s](%s) %s\n", b.Name, b.Avatar, b.Name)
+			} else {
+				fmt.Fprintf(buf, "- %s\n", b.Name)
+			}
+		}
+		buf.WriteString("\n## Conversation\n\n")
+	}
+	for _, msg := range t.Messages {
+		fmt.Fprintf(buf, "**%s**", msg.Author)
+		if msg.Bot {
+			buf.WriteString(" (bot)")
+		}
+		fmt.Fprintf(buf, " _%s_\n\n%s\n\n", msg.Timestamp.Format("2006-01-02 15:04"), msg.Content)
+	}
+}
+
+var transcriptTemplate = template.Must(template.New("transcript").Parse(`<!DOCTYPE html>
+<html>
+<head>
+<meta charset="utf-8">
+<title>#{{.Channel}}</title>
+<style>
+body { font-family: sans-serif; max-width: 48rem; margin: 2rem auto; padding: 0 1rem; }
+.message { display: flex; gap: .75rem; margin: 1rem 0; }
+.avatar { width: 2.5rem; height: 2.5rem; flex-shrink: 0; border-radius: .25rem; background: #ddd center / cover; }
+.author { font-weight: bold; }
+time { color: #888; font-size: .8rem; margin-left: .5rem; }
+.content { white-space: pre-wrap; }
+{{.AvatarCSS}}
+</style>
+</head>
+<body>
+<h1>#{{.Channel}}</h1>
+<p>Exported from {{.Provider}} on {{.Exported.Format "2006-01-02"}}.</p>
+{{range .Messages}}<div class="message">
+<div class="avatar {{.Class}}"></div>
+<div><span class="author">{{.Author}}</span><time datetime="{{.Timestamp.Format "2006-01-02T15:04:05Z07:00"}}">{{.Timestamp.Format "2006-01-02 15:04"}}</time>
+<div class="content">{{.Content}}</div></div>
+</div>
+{{end}}</body>
+</html>
+`))
+
+type htmlTranscriptMessage struct {
+	*TranscriptMessage
+	Class string
+}
+
+// writeHTMLTranscript renders a transcript as a standalone HTML page. Each avatar is embedded once in the style
+// sheet, rather than in every message.
+func writeHTMLTranscript(buf *bytes.Buffer, t *Transcript) error {
+	var css strings.Builder
+	classes := map[string]string{}
+	for i, b := range t.Bots {
+		if b.Avatar == "" {
+			continue
+		}
+		classes[b.Name] = "bot-" + strconv.Itoa(i)
+		// Data URIs only contain the mime type and base64, so they're safe to embed
+		fmt.Fprintf(&css, ".%s { background-image: url(%s); }\n", classes[b.Name], b.Avatar)
+	}
+	msgs := fns.Map(t.Messages, func(m *TranscriptMessage) *htmlTranscriptMessage {
+		msg := &htmlTranscriptMessage{TranscriptMessage: m}
+		if m.Bot {
+			msg.Class = classes[m.Author]
+		}
+		return msg
+	})
+	return errors.Wrap(transcriptTemplate.Execute(buf, map[string]any{
+		"Channel":   t.Channel,
+		"Provider":  t.Provider,
+		"Exported":  t.Exported,
+		"AvatarCSS": template.CSS(css.String()),
+		"Messages":  msgs,
+	}), "execute transcript template")
+}
+
+// fromSlackExport converts the messages of a Slack export to transcript messages. Join messages and other events
+// are skipped, and mentions are rewritten to names.
+func fromSlackExport(msgs []*SlackExportMessage) []*TranscriptMessage {
+	names := map[string]string{}
+	for _, m := range msgs {
+		if m.UserProfile == nil {
+			continue
+		}
+		names[m.User] = m.UserProfile.DisplayName
+		if names[m.User] == "" {
+			names[m.User] = m.UserProfile.RealName
+		}
+	}
+	mention := mentionSyntaxes[db.ProviderSlack].pattern
+	var rtn []*TranscriptMessage
+	for _, m := range msgs {
+		switch {
+		case m.Type != "message":
+			continue
+		case m.Subtype != "" && m.Subtype != "bot_message" && m.Subtype != "thread_broadcast" && m.Subtype != "file_share":
+			continue
+		}
+		author := names[m.User]
+		if author == "" {
+			author = m.Username
+		}
+		if author == "" {
+			author = m.User
+		}
+		content := mention.ReplaceAllStringFunc(m.Text, func(s string) string {
+			id := mention.FindStringSubmatch(s)[1]
+			if name, ok := names[id]; ok && name != "" {
+				return "@" + name
+			}
+			return s
+		})
+		rtn = append(rtn, &TranscriptMessage{
+			Author:    author,
+			Bot:       m.Subtype == "bot_message",
+			Timestamp: slackTimestamp(m.Ts),
+			Content:   content,
+		})
+	}
+	return rtn
+}
+
+// slackTimestamp parses a Slack timestamp, e.g. 1512085950.000216. It returns the zero time if it's invalid.
+func slackTimestamp(ts string) time.Time {
+	secs, frac, _ := strings.Cut(ts, ".")
+	s, err := strconv.ParseUint(secs, 10, 63)
+	if err != nil {
+		return time.Time{}
+	}
+	var us uint64
+	if frac != "" {
+		// The fraction is in microseconds, but shorter fractions are accepted too, e.g. 1512085950.5
+		frac = (frac + "000000")[:6]
+		us, err = strconv.ParseUint(frac, 10, 32)
+		if err != nil {
+			return time.Time{}
+		}
+	}
+	return time.Unix(int64(s), int64(us)*int64(time.Microsecond)).UTC()
+}
+
+// formatSlackTimestamp formats a time as a Slack timestamp, the inverse of slackTimestamp
+func formatSlackTimestamp(t time.Time) string {
+	return fmt.Sprintf("%d.%06d", t.Unix(), t.Nanosecond()/int(time.Microsecond))
+}
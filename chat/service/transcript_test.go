@@ -0,0 +1,60 @@
+package chat
+
+import (
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestSlackTimestamp(t *testing.T) {
+	tests := []struct {
+		ts   string
+		want time.Time
+	}{
+		{ts: "1512085950.000216", want: time.Unix(1512085950, 216000).UTC()},
+		{ts: "1512085950.5", want: time.Unix(1512085950, 500000000).UTC()},
+		{ts: "1512085950", want: time.Unix(1512085950, 0).UTC()},
+		{ts: "1512085950.0002169", want: time.Unix(1512085950, 216000).UTC()},
+		{ts: "", want: time.Time{}},
+		{ts: "abc.000216", want: time.Time{}},
+		{ts: "1512085950.x", want: time.Time{}},
+		{ts: "-1512085950.000216", want: time.Time{}},
+		{ts: "3c5e5b1a-5e0e-4a4e-9a6e-1f0e0e0e0e0e", want: time.Time{}},
+	}
+	for _, tt := range tests {
+		if got := slackTimestamp(tt.ts); !got.Equal(tt.want) {
+			t.Errorf("slackTimestamp(%q) = %v, want %v", tt.ts, got, tt.want)
+		}
+	}
+	for _, ts := range []string{"1512085950.000216", "1512085950.000000"} {
+		if got := formatSlackTimestamp(slackTimestamp(ts)); got != ts {
+			t.Errorf("formatSlackTimestamp(slackTimestamp(%q)) = %q", ts, got)
+		}
+	}
+}
+
+func TestFromSlackExport(t *testing.T) {
+	msgs := []*SlackExportMessage{
+		{Type: "message", Subtype: "channel_join", User: "U1", Text: "<@U1> has joined the channel", Ts: "1512085950.000001"},
+		{Type: "message", User: "U1", Text: "hi <@U2>", Ts: "1512085950.000216",
+			UserProfile: &SlackUserProfile{RealName: "Ann Lee", DisplayName: "ann"}},
+		{Type: "message", User: "U2", Text: "hey <@U1|ann> and <@U3>", Ts: "1512085960.000000",
+			UserProfile: &SlackUserProfile{RealName: "Bob"}},
+		{Type: "message", Subtype: "bot_message", Username: "Grumpy", Text: "meh", Ts: "1512085970.000000"},
+		{Type: "message", Subtype: "thread_broadcast", User: "U3", Text: "also in the channel", Ts: "1512085980.000000"},
+		{Type: "reaction_added", User: "U1", Ts: "1512085990.000000"},
+	}
+	want := []*TranscriptMessage{
+		{Author: "ann", Timestamp: time.Unix(1512085950, 216000).UTC(), Content: "hi @Bob"},
+		{Author: "Bob", Timestamp: time.Unix(1512085960, 0).UTC(), Content: "hey @ann and <@U3>"},
+		{Author: "Grumpy", Bot: true, Timestamp: time.Unix(1512085970, 0).UTC(), Content: "meh"},
+		{Author: "U3", Timestamp: time.Unix(1512085980, 0).UTC(), Content: "also in the channel"},
+	}
+	got := fromSlackExport(msgs)
+	if !reflect.DeepEqual(got, want) {
+		for i := range got {
+			t.Logf("got %d: %+v", i, *got[i])
+		}
+		t.Errorf("fromSlackExport returned %d messages, want %d", len(got), len(want))
+	}
+}
@@ -0,0 +1,548 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"mime/multipart"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/gorilla/websocket"
+)
+
+const (
+	// Time allowed to write a message to the server.
+	writeWait = 10 * time.Second
+
+	// Bounds for the reconnect backoff.
+	minBackoff = time.Second
+	maxBackoff = 30 * time.Second
+
+	// Typing indicators for the same user are only rendered once within this window.
+	typingDebounce = 5 * time.Second
+
+	// The local chat provider closes connections which send larger messages.
+	maxMessageSize = 512
+)
+
+// clientMessage mirrors chat.ClientMessage in the local chat provider. It's redeclared here to avoid
+// pulling Encore runtime dependencies into the command.
+type clientMessage struct {
+	ID               string         `json:"id,omitempty"`
+	Type             string         `json:"type"`
+	UserId           string         `json:"userId,omitempty"`
+	ConversationId   string         `json:"conversationId,omitempty"`
+	ConversationName string         `json:"conversationName,omitempty"`
+	Content          string         `json:"content,omitempty"`
+	Avatar           string         `json:"avatar,omitempty"`
+	Username         string         `json:"username,omitempty"`
+	Conversations    []conversation `json:"conversations,omitempty"`
+	Timestamp        time.Time      `json:"timestamp"`
+	Bots             []string       `json:"bots,omitempty"`
+	MessageId        string         `json:"messageId,omitempty"`
+	Attachments      []attachment   `json:"attachments,omitempty"`
+}
+
+type attachment struct {
+	Name string `json:"name"`
+	Size int64  `json:"size"`
+}
+
+type conversation struct {
+	ID            string `json:"id"`
+	LastMessageID string `json:"lastMessageId"`
+}
+
+type botInfo struct {
+	ID     string `json:"id"`
+	Name   string `json:"name"`
+	Avatar string `json:"avatar"`
+}
+
+// client keeps a websocket connection to the local chat provider alive and renders incoming messages.
+type client struct {
+	baseURL *url.URL
+	user    string
+	out     io.Writer
+
+	mu       sync.Mutex
+	conn     *websocket.Conn
+	ready    chan struct{}
+	active   string
+	channels map[string]*channelState
+	names    map[string]string
+	typing   map[string]time.Time
+
+	outMu sync.Mutex
+}
+
+// channelState tracks what the client has seen in a channel to be able to resume after a reconnect.
+type channelState struct {
+	name          string
+	joined        bool
+	lastMessageID string
+	seen          map[string]bool
+	authors       map[string]string
+}
+
+func newClient(addr, user string, out io.Writer) (*client, error) {
+	baseURL, err := url.Parse(addr)
+	if err != nil {
+		return nil, fmt.Errorf("parse address: %w", err)
+	}
+	return &client{
+		baseURL:  baseURL,
+		user:     user,
+		out:      out,
+		ready:    make(chan struct{}),
+		channels: map[string]*channelState{},
+		names:    map[string]string{},
+		typing:   map[string]time.Time{},
+	}, nil
+}
+
+// run connects to the server and reads messages until the context is cancelled. Dropped connections are
+// re-established with an exponential backoff and resumed from the last seen message in each channel.
+func (c *client) run(ctx context.Context) {
+	backoff := minBackoff
+	for {
+		conn, err := c.dial(ctx)
+		if err != nil {
+			if ctx.Err() != nil {
+				return
+			}
+			c.printf("! connect failed: %v, retrying in %s\n", err, backoff)
+			select {
+			case <-ctx.Done():
+				return
+			case <-time.After(backoff):
+			}
+			backoff = min(backoff*2, maxBackoff)
+			continue
+		}
+		backoff = minBackoff
+		c.setConn(conn)
+		c.readLoop(ctx, conn)
+		c.setConn(nil)
+		if ctx.Err() != nil {
+			return
+		}
+		c.printf("! disconnected, reconnecting...\n")
+	}
+}
+
+func (c *client) dial(ctx context.Context) (*websocket.Conn, error) {
+	wsURL := *c.baseURL.JoinPath("localchat", "subscribe")
+	switch wsURL.Scheme {
+	case "https":
+		wsURL.Scheme = "wss"
+	default:
+		wsURL.Scheme = "ws"
+	}
+	conn, _, err := websocket.DefaultDialer.DialContext(ctx, wsURL.String(), nil)
+	if err != nil {
+		return nil, err
+	}
+	go func() {
+		<-ctx.Done()
+		_ = conn.Close()
+	}()
+	return conn, nil
+}
+
+// setConn swaps the active connection. When a connection is established after a disconnect, the server
+// is asked to resend everything that happened in the joined channels since the last seen message.
+func (c *client) setConn(conn *websocket.Conn) {
+	c.mu.Lock()
+	var convs []conversation
+	for id, ch := range c.channels {
+		if ch.joined {
+			convs = append(convs, conversation{ID: id, LastMessageID: ch.lastMessageID})
+		}
+	}
+	reconnect := c.conn == nil && conn != nil && len(convs) > 0
+	c.conn = conn
+	if conn != nil {
+		select {
+		case <-c.ready:
+		default:
+			close(c.ready)
+		}
+	} else {
+		c.ready = make(chan struct{})
+	}
+	c.mu.Unlock()
+	if reconnect {
+		err := c.write(&clientMessage{
+			Type:          "reconnect",
+			UserId:        c.user,
+			Conversations: convs,
+		})
+		if err != nil {
+			c.printf("! resume channels: %v\n", err)
+		}
+	}
+}
+
+func (c *client) readLoop(ctx context.Context, conn *websocket.Conn) {
+	for {
+		_, data, err := conn.ReadMessage()
+		if err != nil {
+			if ctx.Err() == nil && !websocket.IsCloseError(err, websocket.CloseNormalClosure) {
+				c.printf("! read: %v\n", err)
+			}
+			return
+		}
+		var msg clientMessage
+		if err := json.Unmarshal(data, &msg); err != nil {
+			c.printf("! invalid message from server: %v\n", err)
+			continue
+		}
+		c.render(&msg)
+	}
+}
+
+// render prints a message from the server to the terminal.
+func (c *client) render(msg *clientMessage) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	ch := c.channel(msg.ConversationId)
+	switch msg.Type {
+	case "channel_info":
+		if msg.ConversationName != "" {
+			ch.name = msg.ConversationName
+		}
+		c.printf("* joined #%s\n", ch.name)
+	case "join":
+		if msg.UserId == c.user {
+			return
+		}
+		name := msg.Username
+		if name == "" {
+			name = msg.UserId
+		}
+		if _, known := c.names[msg.UserId]; !known {
+			c.printf("* %s is in #%s\n", name, ch.name)
+		}
+		c.names[msg.UserId] = name
+	case "leave":
+		c.printf("* %s left #%s\n", c.displayName(msg.UserId), ch.name)
+	case "typing":
+		if msg.UserId == c.user || time.Since(c.typing[msg.UserId]) < typingDebounce {
+			return
+		}
+		c.typing[msg.UserId] = time.Now()
+		c.printf("  %s is typing...\n", c.displayName(msg.UserId))
+	case "message":
+		if msg.ID != "" {
+			if ch.seen[msg.ID] {
+				return
+			}
+			ch.seen[msg.ID] = true
+			ch.lastMessageID = msg.ID
+			ch.authors[msg.ID] = msg.UserId
+		}
+		delete(c.typing, msg.UserId)
+		ts := msg.Timestamp
+		if ts.IsZero() {
+			ts = time.Now()
+		}
+		prefix := ""
+		if msg.ConversationId != c.active {
+			prefix = "#" + ch.name + " "
+		}
+		c.printf("[%s] %s%s: %s\n", ts.Local().Format("15:04"), prefix, c.displayName(msg.UserId), msg.Content)
+		for _, a := range msg.Attachments {
+			c.printf("  attached %s (%d bytes)\n", a.Name, a.Size)
+		}
+	case "reaction":
+		if msg.ID != "" {
+			if ch.seen[msg.ID] {
+				return
+			}
+			ch.seen[msg.ID] = true
+		}
+		target := "a message"
+		if author, ok := ch.authors[msg.MessageId]; ok {
+			target = c.displayName(author) + "'s message"
+		}
+		c.printf("  %s reacted %s to %s\n", c.displayName(msg.UserId), msg.Content, target)
+	}
+}
+
+// channel returns the state of a channel and creates it if it doesn't exist. The caller must hold c.mu.
+func (c *client) channel(id string) *channelState {
+	ch, ok := c.channels[id]
+	if !ok {
+		ch = &channelState{name: id, seen: map[string]bool{}, authors: map[string]string{}}
+		c.channels[id] = ch
+	}
+	return ch
+}
+
+// displayName returns the name announced for a user ID. The caller must hold c.mu.
+func (c *client) displayName(userID string) string {
+	if name, ok := c.names[userID]; ok {
+		return name
+	}
+	return userID
+}
+
+// join joins (or creates) a channel and makes it the active channel. The bots are only used by the server
+// when the channel doesn't exist yet.
+func (c *client) join(ctx context.Context, channel string, botNames []string) error {
+	var botIDs []string
+	if len(botNames) > 0 {
+		bots, err := c.resolveBots(ctx, botNames...)
+		if err != nil {
+			return err
+		}
+		for _, b := range bots {
+			botIDs = append(botIDs, b.ID)
+		}
+	}
+	c.mu.Lock()
+	c.channel(channel)
+	c.active = channel
+	ready := c.ready
+	c.mu.Unlock()
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-ready:
+	}
+	err := c.write(&clientMessage{
+		Type:             "join",
+		UserId:           c.user,
+		Username:         c.user,
+		ConversationId:   channel,
+		ConversationName: channel,
+		Bots:             botIDs,
+	})
+	if err != nil {
+		return err
+	}
+	c.mu.Lock()
+	c.channel(channel).joined = true
+	c.mu.Unlock()
+	return nil
+}
+
+// send sends a chat message to the active channel.
+func (c *client) send(content string) error {
+	if len(content) > maxMessageSize/2 {
+		return fmt.Errorf("message is too long, keep it under %d characters", maxMessageSize/2)
+	}
+	c.mu.Lock()
+	channel := c.active
+	c.mu.Unlock()
+	if channel == "" {
+		return fmt.Errorf("join a channel first")
+	}
+	return c.write(&clientMessage{
+		ID:             uuid.New().String(),
+		Type:           "message",
+		UserId:         c.user,
+		ConversationId: channel,
+		Content:        content,
+		Timestamp:      time.Now().UTC(),
+	})
+}
+
+// upload uploads a file with an optional message to the active channel. Files are sent over HTTP, as the
+// websocket only accepts small messages.
+func (c *client) upload(ctx context.Context, path, content string) error {
+	if path == "" {
+		return fmt.Errorf("usage: /upload <file> [message]")
+	}
+	c.mu.Lock()
+	channel := c.active
+	c.mu.Unlock()
+	if channel == "" {
+		return fmt.Errorf("join a channel first")
+	}
+	f, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	var body bytes.Buffer
+	w := multipart.NewWriter(&body)
+	part, err := w.CreateFormFile("file", filepath.Base(path))
+	if err != nil {
+		return fmt.Errorf("create form: %w", err)
+	}
+	if _, err := io.Copy(part, f); err != nil {
+		return fmt.Errorf("read file: %w", err)
+	}
+	_ = w.WriteField("userId", c.user)
+	_ = w.WriteField("content", content)
+	if err := w.Close(); err != nil {
+		return fmt.Errorf("create form: %w", err)
+	}
+	u := c.baseURL.JoinPath("localchat", "channels", channel, "uploads")
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, u.String(), &body)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", w.FormDataContentType())
+	_, err = c.doRequest(req)
+	return err
+}
+
+// react reacts with an emoji to the latest message in the active channel.
+func (c *client) react(emoji string) error {
+	if emoji == "" {
+		return fmt.Errorf("usage: /react <emoji>")
+	}
+	c.mu.Lock()
+	channel := c.active
+	target := ""
+	if ch, ok := c.channels[channel]; ok {
+		target = ch.lastMessageID
+	}
+	c.mu.Unlock()
+	if target == "" {
+		return fmt.Errorf("there is no message to react to")
+	}
+	return c.write(&clientMessage{
+		ID:             uuid.New().String(),
+		Type:           "reaction",
+		UserId:         c.user,
+		ConversationId: channel,
+		Content:        emoji,
+		MessageId:      target,
+		Timestamp:      time.Now().UTC(),
+	})
+}
+
+func (c *client) write(msg *clientMessage) error {
+	c.mu.Lock()
+	conn := c.conn
+	c.mu.Unlock()
+	if conn == nil {
+		return fmt.Errorf("not connected")
+	}
+	data, err := json.Marshal(msg)
+	if err != nil {
+		return fmt.Errorf("marshal message: %w", err)
+	}
+	c.outMu.Lock()
+	defer c.outMu.Unlock()
+	if err := conn.SetWriteDeadline(time.Now().Add(writeWait)); err != nil {
+		return fmt.Errorf("set write deadline: %w", err)
+	}
+	return conn.WriteMessage(websocket.TextMessage, data)
+}
+
+// printBots lists all bots which can be added to channels.
+func (c *client) printBots(ctx context.Context) error {
+	bots, err := c.listBots(ctx)
+	if err != nil {
+		return err
+	}
+	for _, b := range bots {
+		c.printf("  %s (%s)\n", b.Name, b.ID)
+	}
+	return nil
+}
+
+// addBot adds a bot to the active channel using the chat service API.
+func (c *client) addBot(ctx context.Context, name string) error {
+	return c.botChannelRequest(ctx, http.MethodPost, name)
+}
+
+// removeBot removes a bot from the active channel using the chat service API.
+func (c *client) removeBot(ctx context.Context, name string) error {
+	return c.botChannelRequest(ctx, http.MethodDelete, name)
+}
+
+func (c *client) botChannelRequest(ctx context.Context, method, name string) error {
+	if name == "" {
+		return fmt.Errorf("a bot name is required")
+	}
+	bots, err := c.resolveBots(ctx, name)
+	if err != nil {
+		return err
+	}
+	c.mu.Lock()
+	channel := c.active
+	c.mu.Unlock()
+	path := c.baseURL.JoinPath("chat", "provider", "localchat", "channels", channel, "bots", bots[0].ID)
+	_, err = c.do(ctx, method, path.String())
+	return err
+}
+
+// resolveBots looks up bots by name (case-insensitive) or ID.
+func (c *client) resolveBots(ctx context.Context, names ...string) ([]botInfo, error) {
+	bots, err := c.listBots(ctx)
+	if err != nil {
+		return nil, err
+	}
+	var rtn []botInfo
+	for _, name := range names {
+		found := false
+		for _, b := range bots {
+			if b.ID == name || strings.EqualFold(b.Name, name) {
+				rtn = append(rtn, b)
+				found = true
+				break
+			}
+		}
+		if !found {
+			return nil, fmt.Errorf("unknown bot %q, use /bots to list available bots", name)
+		}
+	}
+	return rtn, nil
+}
+
+func (c *client) listBots(ctx context.Context) ([]botInfo, error) {
+	data, err := c.do(ctx, http.MethodGet, c.baseURL.JoinPath("localchat", "bots").String())
+	if err != nil {
+		return nil, err
+	}
+	var resp struct {
+		Bots []botInfo `json:"bots"`
+	}
+	if err := json.Unmarshal(data, &resp); err != nil {
+		return nil, fmt.Errorf("decode bots: %w", err)
+	}
+	return resp.Bots, nil
+}
+
+func (c *client) do(ctx context.Context, method, url string) ([]byte, error) {
+	req, err := http.NewRequestWithContext(ctx, method, url, nil)
+	if err != nil {
+		return nil, err
+	}
+	return c.doRequest(req)
+}
+
+func (c *client) doRequest(req *http.Request) ([]byte, error) {
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	data, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode >= 300 {
+		return nil, fmt.Errorf("%s %s: %s", req.Method, req.URL.Path, strings.TrimSpace(string(data)))
+	}
+	return data, nil
+}
+
+func (c *client) printf(format string, args ...any) {
+	fmt.Fprintf(c.out, format, args...)
+}
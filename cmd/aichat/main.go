@@ -0,0 +1,140 @@
+// Command aichat is a terminal client for the local chat provider. It speaks the same websocket protocol as the
+// hosted web chat, which makes it possible to chat with (and test) bots without running the React frontend.
+//
+// Usage:
+//
+//	go run ./cmd/aichat -user alice -channel general -bots Grumpy,Cheerful
+//
+// Lines typed into the terminal are sent as messages to the active channel. Lines starting with a slash are
+// commands, type /help to list them.
+package main
+
+import (
+	"bufio"
+	"context"
+	"flag"
+	"fmt"
+	"os"
+	"os/signal"
+	"strings"
+	"syscall"
+)
+
+func main() {
+	addr := flag.String("addr", "http://localhost:4000", "base URL of the Encore app")
+	user := flag.String("user", os.Getenv("USER"), "user name to chat as")
+	channel := flag.String("channel", "general", "channel to join on startup")
+	bots := flag.String("bots", "", "comma separated bot names or IDs to add when creating a new channel")
+	flag.Parse()
+
+	if *user == "" {
+		fmt.Fprintln(os.Stderr, "a user name is required, set it with -user")
+		os.Exit(2)
+	}
+
+	ctx, cancel := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer cancel()
+
+	c, err := newClient(*addr, *user, os.Stdout)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+	go c.run(ctx)
+
+	if err := c.join(ctx, *channel, splitList(*bots)); err != nil {
+		fmt.Fprintln(os.Stderr, err)
+	}
+
+	lines := make(chan string)
+	go func() {
+		defer close(lines)
+		scanner := bufio.NewScanner(os.Stdin)
+		for scanner.Scan() {
+			lines <- scanner.Text()
+		}
+	}()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case line, ok := <-lines:
+			if !ok {
+				return
+			}
+			if quit := handleLine(ctx, c, line); quit {
+				return
+			}
+		}
+	}
+}
+
+// handleLine executes a command or sends the line as a message to the active channel. It returns true if the
+// client should exit.
+func handleLine(ctx context.Context, c *client, line string) bool {
+	line = strings.TrimSpace(line)
+	if line == "" {
+		return false
+	}
+	if !strings.HasPrefix(line, "/") {
+		if err := c.send(line); err != nil {
+			c.printf("! %v\n", err)
+		}
+		return false
+	}
+	cmd, args, _ := strings.Cut(strings.TrimPrefix(line, "/"), " ")
+	args = strings.TrimSpace(args)
+	var err error
+	switch cmd {
+	case "quit", "exit":
+		return true
+	case "help":
+		c.printf("%s", helpText)
+	case "join":
+		name, bots, _ := strings.Cut(args, " ")
+		if name == "" {
+			err = fmt.Errorf("usage: /join <channel> [bot,bot...]")
+			break
+		}
+		err = c.join(ctx, name, splitList(bots))
+	case "bots":
+		err = c.printBots(ctx)
+	case "add":
+		err = c.addBot(ctx, args)
+	case "remove":
+		err = c.removeBot(ctx, args)
+	case "react":
+		err = c.react(args)
+	case "upload":
+		path, content, _ := strings.Cut(args, " ")
+		err = c.upload(ctx, path, strings.TrimSpace(content))
+	default:
+		err = fmt.Errorf("unknown command /%s, type /help for a list of commands", cmd)
+	}
+	if err != nil {
+		c.printf("! %v\n", err)
+	}
+	return false
+}
+
+const helpText = `Commands:
+  /join <channel> [bot,bot...]  join or create a channel, bots are only used for new channels
+  /bots                         list all available bots
+  /add <bot>                    add a bot to the active channel
+  /remove <bot>                 remove a bot from the active channel
+  /react <emoji>                react to the latest message in the active channel
+  /upload <file> [message]      upload a file with an optional message to the active channel
+  /quit                         exit the client
+`
+
+// splitList splits a comma separated list and drops empty entries.
+func splitList(s string) []string {
+	var rtn []string
+	for _, part := range strings.Split(s, ",") {
+		if part = strings.TrimSpace(part); part != "" {
+			rtn = append(rtn, part)
+		}
+	}
+	return rtn
+}
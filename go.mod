@@ -11,6 +11,7 @@ require (
 	github.com/gorilla/websocket v1.4.2
 	github.com/lib/pq v1.10.9
 	github.com/nfnt/resize v0.0.0-20180221191011-83c6a9932646
+	github.com/robfig/cron/v3 v3.0.1
 	github.com/sashabaranov/go-openai v1.24.0
 	github.com/slack-go/slack v0.13.0
 	golang.ngrok.com/ngrok v1.9.1
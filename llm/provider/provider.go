@@ -12,6 +12,7 @@ import (
 	"github.com/cockroachdb/errors"
 
 	botdb "encore.app/bot/db"
+	chatprovider "encore.app/chat/provider"
 	chatdb "encore.app/chat/service/db"
 	"encore.dev/pubsub"
 	"encore.dev/rlog"
@@ -21,8 +22,9 @@ import (
 type BotMessageType string
 
 const (
-	BotMessageTypeText   BotMessageType = "text"
-	BotMessageTypeTyping BotMessageType = "typing"
+	BotMessageTypeText     BotMessageType = "text"
+	BotMessageTypeTyping   BotMessageType = "typing"
+	BotMessageTypeReaction BotMessageType = "reaction"
 )
 
 // BotMessage is a response generated for a bot by the LLM.
@@ -31,6 +33,8 @@ type BotMessage struct {
 	Content string
 	Time    time.Time
 	Type    BotMessageType
+	// ReactTo is the message a reaction is added to. It's only set for reactions, in which case Content is the emoji.
+	ReactTo *chatdb.Message
 }
 
 type ContinueChatResponse struct {
@@ -42,16 +46,24 @@ type ChatRequest struct {
 	Bots      []*botdb.Bot
 	Users     []*chatdb.User
 	Messages  []*chatdb.Message
-	Channel   *chatdb.Channel
-	SystemMsg string
-	Provider  string
-	Type      TaskType
+	Reactions []*chatdb.Reaction
+	// Attachments are the files, links and code snippets attached to the messages
+	Attachments []*chatdb.Attachment
+	Channel     *chatdb.Channel
+	SystemMsg   string
+	Provider    string
+	Type        TaskType
+	// ThreadID is set when the conversation happens in a thread. Messages then only contain the thread
+	// and the message that started it.
+	ThreadID string
 
 	// Cached maps to avoid repeated lookups
-	botsByID   map[uuid.UUID]*botdb.Bot
-	botsByName map[string]*botdb.Bot
-	usersByID  map[uuid.UUID]*chatdb.User
-	buffer     strings.Builder
+	botsByID    map[uuid.UUID]*botdb.Bot
+	botsByName  map[string]*botdb.Bot
+	usersByID   map[uuid.UUID]*chatdb.User
+	reactions   map[uuid.UUID][]*chatdb.Reaction
+	attachments map[uuid.UUID][]*chatdb.Attachment
+	buffer      strings.Builder
 }
 
 var unknownUser = &chatdb.User{
@@ -68,7 +80,105 @@ func (req *ChatRequest) Format(msg *chatdb.Message) string {
 	if bot != nil {
 		name = bot.Name
 	}
-	return fmt.Sprintf("%s %s/%s: %s", msg.Timestamp.Format("01-02 15:04"), req.Channel.Name, name, msg.Content)
+	channel := req.Channel.Name
+	if msg.ThreadID != "" {
+		channel += "/thread"
+	}
+	return fmt.Sprintf("%s %s/%s: %s%s%s", msg.Timestamp.Format("01-02 15:04"), channel, name, msg.Content, req.formatAttachments(msg), req.formatReactions(msg))
+}
+
+// formatAttachments summarizes the attachments of a message, e.g. ` [attached: report.pdf, 120 KB]`. The text of
+// text files and code snippets is included in a code block. It returns an empty string if there are no attachments.
+func (req *ChatRequest) formatAttachments(msg *chatdb.Message) string {
+	if req.attachments == nil {
+		req.attachments = make(map[uuid.UUID][]*chatdb.Attachment)
+		for _, a := range req.Attachments {
+			req.attachments[a.MessageID] = append(req.attachments[a.MessageID], a)
+		}
+	}
+	res := strings.Builder{}
+	for _, a := range req.attachments[msg.ID] {
+		switch a.Type {
+		case chatprovider.AttachmentTypeLink:
+			res.WriteString(" [link: " + a.Name)
+			if a.Description != "" {
+				res.WriteString(" - " + a.Description)
+			}
+			res.WriteString(" (" + a.URL + ")]")
+		case chatprovider.AttachmentTypeImage:
+			res.WriteString(" [image: " + a.Name + "]")
+		default:
+			res.WriteString(" [attached: " + a.Name)
+			if a.Size > 0 {
+				res.WriteString(", " + formatSize(a.Size))
+			}
+			res.WriteString("]")
+		}
+		if a.Text != "" {
+			res.WriteString("\n```\n" + strings.TrimSuffix(a.Text, "\n") + "\n```")
+		}
+	}
+	return res.String()
+}
+
+// formatSize formats a file size in bytes, e.g. `120 KB`
+func formatSize(size int64) string {
+	const unit = 1024
+	if size < unit {
+		return fmt.Sprintf("%d B", size)
+	}
+	div, exp := int64(unit), 0
+	for n := size / unit; n >= unit && exp < 3; n /= unit {
+		div *= unit
+		exp++
+	}
+	return fmt.Sprintf("%.0f %cB", float64(size)/float64(div), "KMGT"[exp])
+}
+
+// formatReactions formats the reactions to a message, e.g. ` [reactions: 👍 Alice, 😂 Bob]`. It returns an empty
+// string if there are no reactions.
+func (req *ChatRequest) formatReactions(msg *chatdb.Message) string {
+	if req.reactions == nil {
+		req.reactions = make(map[uuid.UUID][]*chatdb.Reaction)
+		for _, r := range req.Reactions {
+			req.reactions[r.MessageID] = append(req.reactions[r.MessageID], r)
+		}
+	}
+	reactions := req.reactions[msg.ID]
+	if len(reactions) == 0 {
+		return ""
+	}
+	res := strings.Builder{}
+	res.WriteString(" [reactions: ")
+	for i, r := range reactions {
+		if i > 0 {
+			res.WriteString(", ")
+		}
+		user, bot := req.UserForMessage(&chatdb.Message{AuthorID: r.UserID})
+		name := user.Name
+		if bot != nil {
+			name = bot.Name
+		}
+		res.WriteString(r.Emoji + " " + name)
+	}
+	res.WriteString("]")
+	return res.String()
+}
+
+// reactionTarget returns the message a bot reacts to, which is the latest message that wasn't sent by the bot
+// itself or by Admin.
+func (req *ChatRequest) reactionTarget(botID uuid.UUID) *chatdb.Message {
+	for i := len(req.Messages) - 1; i >= 0; i-- {
+		msg := req.Messages[i]
+		if msg.AuthorID == chatdb.Admin.ID || msg.ProviderID == "" {
+			continue
+		}
+		if _, bot := req.UserForMessage(msg); bot != nil && bot.ID == botID {
+			continue
+		}
+		return msg
+	}
+	return nil
 }
 
 // FromBot returns true if the message was sent by a bot.
@@ -137,6 +247,7 @@ var LLMMessageTopic = pubsub.NewTopic[*BotResponse]("llm-messages", pubsub.Topic
 type BotResponse struct {
 	TaskType TaskType
 	Channel  *chatdb.Channel
+	ThreadID string
 	Messages []*BotMessage
 }
 
@@ -148,6 +259,7 @@ const (
 	TaskTypeContinue    TaskType = "continue"
 	TaskTypeInstruct    TaskType = "instruct"
 	TaskTypePrepopulate TaskType = "prepopulate"
+	TaskTypeDirect      TaskType = "direct"
 )
 
 func (s *ChatRequest) Write(ctx context.Context, p string) (err error) {
@@ -184,6 +296,8 @@ func (s *ChatRequest) processLine(ctx context.Context, line string) error {
 	if botID == "none" {
 		return nil
 	}
+	// Reactions are formatted as `<id> react: "<emoji>"`
+	botID, isReaction := strings.CutSuffix(strings.TrimSpace(botID), " react")
 	botIx, err := strconv.ParseInt(strings.TrimSpace(botID), 10, 64)
 	if err != nil || botIx < 0 || int(botIx) >= len(s.Bots) {
 		rlog.Warn("parse bot ID", "error", err, "botID", botID)
@@ -196,11 +310,15 @@ func (s *ChatRequest) processLine(ctx context.Context, line string) error {
 	} else {
 		msg = unMsg
 	}
+	if isReaction {
+		return s.react(ctx, s.Bots[botIx].ID, strings.TrimSpace(msg))
+	}
 	// Simulate the bot reading
 	time.Sleep(time.Duration(1000+rand.IntN(2000)) * time.Millisecond)
 	_, err = LLMMessageTopic.Publish(ctx, &BotResponse{
 		TaskType: s.Type,
 		Channel:  s.Channel,
+		ThreadID: s.ThreadID,
 		Messages: []*BotMessage{{
 			Bot:     s.Bots[botIx].ID,
 			Content: msg,
@@ -215,6 +333,7 @@ func (s *ChatRequest) processLine(ctx context.Context, line string) error {
 	_, err = LLMMessageTopic.Publish(ctx, &BotResponse{
 		TaskType: s.Type,
 		Channel:  s.Channel,
+		ThreadID: s.ThreadID,
 		Messages: []*BotMessage{{
 			Bot:     s.Bots[botIx].ID,
 			Content: msg,
@@ -227,3 +346,30 @@ func (s *ChatRequest) processLine(ctx context.Context, line string) error {
 	}
 	return nil
 }
+
+// react publishes a reaction from a bot to the latest message in the conversation.
+func (s *ChatRequest) react(ctx context.Context, botID uuid.UUID, emoji string) error {
+	target := s.reactionTarget(botID)
+	if target == nil || emoji == "" {
+		rlog.Warn("invalid reaction", "emoji", emoji)
+		return nil
+	}
+	// Simulate the bot reading
+	time.Sleep(time.Duration(500+rand.IntN(1500)) * time.Millisecond)
+	_, err := LLMMessageTopic.Publish(ctx, &BotResponse{
+		TaskType: s.Type,
+		Channel:  s.Channel,
+		ThreadID: s.ThreadID,
+		Messages: []*BotMessage{{
+			Bot:     botID,
+			Content: emoji,
+			Time:    time.Now(),
+			Type:    BotMessageTypeReaction,
+			ReactTo: target,
+		}},
+	})
+	if err != nil {
+		rlog.Warn("publish reaction", "error", err)
+	}
+	return nil
+}
@@ -24,6 +24,21 @@ var avatarPrompt []byte
 //go:embed prompts/persona.txt
 var personaPrompt []byte
 
+//go:embed prompts/messages.txt
+var messagesPrompt []byte
+
+//go:embed prompts/direct.txt
+var directPrompt []byte
+
+//go:embed prompts/direct_persona.txt
+var directPersonaPrompt []byte
+
+//go:embed prompts/direct_response.txt
+var directResponsePrompt []byte
+
+//go:embed prompts/thread.txt
+var threadPrompt []byte
+
 //go:embed prompts/response.txt
 var responsePrompt []byte
 
@@ -43,6 +43,11 @@ func (svc *Service) ProcessTask(ctx context.Context, req *provider.ChatRequest)
 		if err != nil {
 			return errors.Wrap(err, "continue chat")
 		}
+	case provider.TaskTypeDirect:
+		_, err = svc.DirectMessage(ctx, req)
+		if err != nil {
+			return errors.Wrap(err, "direct message")
+		}
 	case provider.TaskTypeLeave:
 		_, err = svc.Goodbye(ctx, req)
 		if err != nil {
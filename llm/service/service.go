@@ -90,6 +90,18 @@ func (svc *Service) ContinueChat(ctx context.Context, req *provider.ChatRequest)
 	return svc.continueChat(ctx, req, true)
 }
 
+// DirectMessage replies to a user in a direct message conversation with a single bot.
+//
+//encore:api private path=/ai/direct
+func (svc *Service) DirectMessage(ctx context.Context, req *provider.ChatRequest) (*provider.ContinueChatResponse, error) {
+	if len(req.Bots) != 1 {
+		return nil, errors.Newf("direct messages need a single bot, got %d", len(req.Bots))
+	}
+	req.SystemMsg = req.SystemMsg + string(directPrompt)
+	req.Type = provider.TaskTypeDirect
+	return svc.continueChat(ctx, req, true)
+}
+
 func (svc *Service) Prepopulate(ctx context.Context, req *provider.ChatRequest) (*provider.ContinueChatResponse, error) {
 	req.SystemMsg = req.SystemMsg + fmt.Sprintf(string(prepopulatePrompt), req.Channel.Name)
 	req.Type = provider.TaskTypePrepopulate
@@ -114,6 +126,22 @@ func (svc *Service) Goodbye(ctx context.Context, req *provider.ChatRequest) (*pr
 	return svc.continueChat(ctx, req, true)
 }
 
+// CancelChannelTasks cancels the running tasks of all AI providers in a channel, e.g. when it's deleted.
+//
+//encore:api private method=DELETE path=/ai/channels/:channelID/tasks
+func (svc *Service) CancelChannelTasks(ctx context.Context, channelID uuid.UUID) error {
+	for provider := range svc.providers {
+		err := svc.cancelLastTask(ctx, provider, channelID)
+		if err != nil {
+			return errors.Wrapf(err, "cancel %s task", provider)
+		}
+	}
+	svc.mu.Lock()
+	defer svc.mu.Unlock()
+	delete(svc.channelTasks, channelID)
+	return nil
+}
+
 type GenerateBotProfileRequest struct {
 	Name     string `json:"name"`
 	Prompt   string `json:"prompt"`
@@ -207,13 +235,23 @@ func (svc *Service) continueChat(ctx context.Context, req *provider.ChatRequest,
 	for _, b := range req.Bots {
 		botByName[strings.ToLower(b.Name)] = b
 	}
+	if req.ThreadID != "" {
+		req.SystemMsg = req.SystemMsg + string(threadPrompt)
+	}
+	persona := fmt.Sprintf(string(personaPrompt), formatBotProfiles(req.Bots))
+	response := formatResponsePrompt(req.Bots)
+	if req.Type == provider.TaskTypeDirect {
+		// The group chat prompts would have the bot talk as other characters in a private conversation
+		persona = fmt.Sprintf(string(directPersonaPrompt), req.Bots[0].Name, req.Bots[0].Profile)
+		response = fmt.Sprintf(string(directResponsePrompt), req.Bots[0].Name)
+	}
 	req.Messages = append(req.Messages, &chatdb.Message{
 		ChannelID: req.Channel.ID,
 		AuthorID:  chatdb.Admin.ID,
-		Content:   req.SystemMsg + formatResponsePrompt(req.Bots),
+		Content:   req.SystemMsg + response,
 		Timestamp: time.Now().UTC(),
 	})
-	req.SystemMsg = fmt.Sprintf(string(personaPrompt), formatBotProfiles(req.Bots))
+	req.SystemMsg = persona + string(messagesPrompt)
 	resp, err := prov.ContinueChat(ctx, req)
 	if err != nil {
 		return nil, errors.Wrap(err, "continue chat")